@@ -1,10 +1,17 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	sb "github.com/samtech09/gosql"
 )
 
 func main() {
+	check := flag.Bool("check", false, "report stale generated files as unified diff instead of writing them")
+	flag.Parse()
+
 	fw := sb.NewFileWriter(5)
 
 	stmt := sb.InsertBuilder().Table("users").
@@ -26,5 +33,20 @@ func main() {
 	// export as GO code to ../sqls folder
 	//  exported filename = sqlbuilder
 	//  exported gocode package = sqls
-	fw.Write("../sqls", "sqlbuilder", "sqls", sb.WriteGoCode)
+	if *check {
+		diff, err := fw.Check("../sqls", "sqlbuilder", "sqls", sb.WriteGoCode)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if diff != "" {
+			fmt.Print(diff)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := fw.Write("../sqls", "sqlbuilder", "sqls", sb.WriteGoCode); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 }
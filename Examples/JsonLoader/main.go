@@ -2,13 +2,17 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/samtech09/gosql/Examples/JsonLoader/sqls"
 )
 
 func main() {
 	//load sqls from json file
-	sqls.LoadSQLs("sqls/sqlbuilder.json")
+	if err := sqls.LoadSQLs("sqls/sqlbuilder.json"); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
 	// Execute sql
 	ExecuteQuery(sqls.UserCreate(), "Testuser", "22")
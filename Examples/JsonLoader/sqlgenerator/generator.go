@@ -1,10 +1,17 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	sb "github.com/samtech09/gosql"
 )
 
 func main() {
+	check := flag.Bool("check", false, "report stale generated files as unified diff instead of writing them")
+	flag.Parse()
+
 	fw := sb.NewFileWriter(5)
 
 	stmt := sb.InsertBuilder().Table("users").
@@ -21,5 +28,20 @@ func main() {
 		Build(true)
 	fw.Queue(stmt, "ques", "listForDD", "Gives list of question ID and Title only to fill dropdowns.")
 
-	fw.Write("../sqls", "sqlbuilder", "sqls", sb.WriteJSONandJSONLoaderGoCode)
+	if *check {
+		diff, err := fw.Check("../sqls", "sqlbuilder", "sqls", sb.WriteJSONandJSONLoaderGoCode)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if diff != "" {
+			fmt.Print(diff)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := fw.Write("../sqls", "sqlbuilder", "sqls", sb.WriteJSONandJSONLoaderGoCode); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,638 @@
+package gosql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Builders, conditions, CASE expressions and orderings are marshaled to JSON with all their definition,
+// so a definition can be stored and built again later, for any database type through FileWriter.QueueBuilder.
+// Database type itself is not part of JSON, unmarshaled builders use DATABASE_TYPE like new builders do.
+//
+// JSON of a builder has key 'Type' with one of 'select', 'insert', 'update', 'delete' and 'proc',
+// use UnmarshalBuilder to unmarshal JSON of any builder type. Conditions are stored as field, operator and
+// operands, so they can be reviewed and edited as data.
+
+type selectJSON struct {
+	Type         string
+	Select       []selectItemJSON `json:",omitempty"`
+	From         []fromJSON       `json:",omitempty"`
+	Joins        []joinJSON       `json:",omitempty"`
+	Where        []whereJSON      `json:",omitempty"`
+	GroupBy      []string         `json:",omitempty"`
+	Grouping     string           `json:",omitempty"`
+	GroupingSets [][]string       `json:",omitempty"`
+	OrderBy      []*Ordering      `json:",omitempty"`
+	Distinct     bool             `json:",omitempty"`
+	DistinctOn   []string         `json:",omitempty"`
+	Limit        int              `json:",omitempty"`
+	RowCount     bool             `json:",omitempty"`
+	ReadOnly     bool
+}
+
+type selectItemJSON struct {
+	SQL   string         `json:",omitempty"`
+	Sub   *selectBuilder `json:",omitempty"`
+	Field string         `json:",omitempty"`
+	Case  *CaseExpr      `json:",omitempty"`
+}
+
+type fromJSON struct {
+	Alias string         `json:",omitempty"`
+	Table string         `json:",omitempty"`
+	Sub   *selectBuilder `json:",omitempty"`
+	Proc  *procBuilder   `json:",omitempty"`
+}
+
+type joinJSON struct {
+	Left  bool           `json:",omitempty"`
+	Alias string         `json:",omitempty"`
+	Sub   *selectBuilder `json:",omitempty"`
+	Proc  *procBuilder   `json:",omitempty"`
+	On    []Condition    `json:",omitempty"`
+}
+
+type whereJSON struct {
+	Op         string `json:",omitempty"`
+	Inner      string `json:",omitempty"`
+	Conditions []Condition
+}
+
+type insertJSON struct {
+	Type      string
+	Table     string
+	Columns   []string `json:",omitempty"`
+	Returning []string `json:",omitempty"`
+}
+
+type updateJSON struct {
+	Type      string
+	Table     string
+	Columns   []string         `json:",omitempty"`
+	Calc      []calcColumnJSON `json:",omitempty"`
+	Case      []caseColumnJSON `json:",omitempty"`
+	Where     []whereJSON      `json:",omitempty"`
+	Returning []string         `json:",omitempty"`
+}
+
+type calcColumnJSON struct {
+	Column string
+	Value  string
+}
+
+type caseColumnJSON struct {
+	Column string
+	Case   *CaseExpr
+}
+
+type deleteJSON struct {
+	Type      string
+	Table     string
+	Where     []whereJSON `json:",omitempty"`
+	Returning []string    `json:",omitempty"`
+}
+
+type procJSON struct {
+	Type       string
+	Proc       string
+	Mode       string      `json:",omitempty"`
+	Select     []string    `json:",omitempty"`
+	Args       []argJSON   `json:",omitempty"`
+	NamedArgs  bool        `json:",omitempty"`
+	Alias      string      `json:",omitempty"`
+	ColumnDefs []string    `json:",omitempty"`
+	Where      []whereJSON `json:",omitempty"`
+	OrderBy    []string    `json:",omitempty"`
+	Limit      int         `json:",omitempty"`
+	RowCount   bool        `json:",omitempty"`
+	ReadOnly   bool
+}
+
+type argJSON struct {
+	Name      string
+	Direction string `json:",omitempty"`
+}
+
+// conditionJSON is condition as data like ConditionSpec, Op is one of '=', '!=', '>', '>=', '<', '<=', 'between',
+// 'in', 'not in', 'any' and 'not any'. Operands of 'in' are Values, Ints or Floats as the condition was created with.
+type conditionJSON struct {
+	Field   string
+	Op      string
+	Value   string         `json:",omitempty"`
+	Values  []string       `json:",omitempty"`
+	Ints    []int          `json:",omitempty"`
+	Floats  []float64      `json:",omitempty"`
+	PgArray bool           `json:",omitempty"`
+	Sub     *selectBuilder `json:",omitempty"`
+}
+
+type caseJSON struct {
+	When []caseWhenJSON
+	Else string `json:",omitempty"`
+}
+
+type caseWhenJSON struct {
+	Condition Condition
+	Value     string
+}
+
+type orderingJSON struct {
+	Expr      string    `json:",omitempty"`
+	Position  int       `json:",omitempty"`
+	Case      *CaseExpr `json:",omitempty"`
+	Desc      bool      `json:",omitempty"`
+	Nulls     string    `json:",omitempty"`
+	Collation string    `json:",omitempty"`
+}
+
+var (
+	// names are indexed by value of their constants
+	operatorNames  = []string{"", "and", "or"}
+	groupingNames  = []string{"", "rollup", "cube", "sets"}
+	nullsNames     = []string{"", "first", "last"}
+	directionNames = []string{"", "out", "inout", "expr"}
+)
+
+// MarshalBuilder returns JSON of builder. Unlike json.Marshal it does not escape '<', '>' and '&',
+// which are common in SQL, so JSON stays readable.
+func MarshalBuilder(b Builder) ([]byte, error) {
+	if _, ok := b.(json.Marshaler); !ok {
+		return nil, fmt.Errorf("builder of type %T cannot be marshaled to JSON", b)
+	}
+	return marshalJSON(b)
+}
+
+// marshalJSON returns JSON of v without escaping HTML characters
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// UnmarshalBuilder creates builder of any type from its JSON.
+func UnmarshalBuilder(data []byte) (Builder, error) {
+	var t struct{ Type string }
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+
+	var b interface {
+		Builder
+		json.Unmarshaler
+	}
+	switch t.Type {
+	case "select":
+		b = SelectBuilder()
+	case "insert":
+		b = InsertBuilder()
+	case "update":
+		b = UpdateBuilder()
+	case "delete":
+		b = DeleteBuilder()
+	case "proc":
+		b = ProcBuilder()
+	default:
+		return nil, fmt.Errorf("unknown builder type '%s'", t.Type)
+	}
+	if err := b.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// MarshalJSON returns JSON of select builder.
+func (s *selectBuilder) MarshalJSON() ([]byte, error) {
+	j := selectJSON{
+		Type:         "select",
+		Joins:        make([]joinJSON, 0, len(s.joinsql)),
+		Where:        s.whereJSON(),
+		GroupBy:      s.groupBy,
+		Grouping:     groupingNames[s.grouping],
+		GroupingSets: s.groupingSets,
+		OrderBy:      s.orderBy,
+		Distinct:     s.distinct,
+		DistinctOn:   s.distinctOn,
+		Limit:        s.limitRows,
+		RowCount:     s.rowcount,
+		ReadOnly:     s.readonly,
+	}
+	for _, sq := range s.selectsql {
+		j.Select = append(j.Select, selectItemJSON{sq.sql, sq.subBuilder, sq.fieldname, sq.caseExpr})
+	}
+
+	// tables are written in order of alias, same as in SQL
+	for _, alias := range sortedAliases(s.tables) {
+		t := s.tables[alias]
+		f := fromJSON{Table: t.table, Sub: t.subBuilder, Proc: t.proc}
+		if t.proc == nil {
+			f.Alias = alias
+		}
+		j.From = append(j.From, f)
+	}
+
+	for _, js := range s.joinsql {
+		j.Joins = append(j.Joins, joinJSON{js.jointype == joinLeftLateral, js.alias, js.subBuilder, js.proc, js.conditions})
+	}
+	return marshalJSON(j)
+}
+
+// UnmarshalJSON replaces definition of select builder by given JSON.
+func (s *selectBuilder) UnmarshalJSON(data []byte) error {
+	var j selectJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Type != "select" {
+		return fmt.Errorf("expected select builder, got '%s'", j.Type)
+	}
+
+	*s = *SelectBuilder()
+	for _, sq := range j.Select {
+		s.selectsql = append(s.selectsql, selectSQL{sq.SQL, sq.Sub, sq.Field, sq.Case})
+	}
+	for _, f := range j.From {
+		if f.Proc != nil {
+			s.tables[f.Proc.alias] = fromSQL{"", nil, f.Proc}
+		} else {
+			s.tables[f.Alias] = fromSQL{f.Table, f.Sub, nil}
+		}
+	}
+	for _, js := range j.Joins {
+		jt := joinLateral
+		if js.Left {
+			jt = joinLeftLateral
+		}
+		s.joinsql = append(s.joinsql, joinSQL{jt, js.Alias, js.Sub, js.Proc, js.On})
+	}
+
+	var err error
+	if s.conditionGroups, err = whereGroups(j.Where); err != nil {
+		return err
+	}
+	grouping, err := parseName(groupingNames, j.Grouping, "grouping")
+	if err != nil {
+		return err
+	}
+	s.grouping = groupingType(grouping)
+	s.groupBy = j.GroupBy
+	s.groupingSets = j.GroupingSets
+	s.orderBy = j.OrderBy
+	s.distinct = j.Distinct
+	s.distinctOn = j.DistinctOn
+	s.limitRows = j.Limit
+	s.rowcount = j.RowCount
+	s.readonly = j.ReadOnly
+	return nil
+}
+
+// MarshalJSON returns JSON of insert builder.
+func (n *insertBuilder) MarshalJSON() ([]byte, error) {
+	return marshalJSON(insertJSON{"insert", n.table, n.fields, n.returningFields})
+}
+
+// UnmarshalJSON replaces definition of insert builder by given JSON.
+func (n *insertBuilder) UnmarshalJSON(data []byte) error {
+	var j insertJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Type != "insert" {
+		return fmt.Errorf("expected insert builder, got '%s'", j.Type)
+	}
+
+	*n = *InsertBuilder()
+	n.table = j.Table
+	n.fields = j.Columns
+	n.returningFields = j.Returning
+	return nil
+}
+
+// MarshalJSON returns JSON of update builder.
+func (u *updateBuilder) MarshalJSON() ([]byte, error) {
+	j := updateJSON{
+		Type:      "update",
+		Table:     u.table,
+		Columns:   u.fields,
+		Where:     u.whereJSON(),
+		Returning: u.returningFields,
+	}
+	for _, cc := range u.calcfields {
+		j.Calc = append(j.Calc, calcColumnJSON{cc.col, cc.value})
+	}
+	for _, cc := range u.casefields {
+		j.Case = append(j.Case, caseColumnJSON{cc.col, cc.expr})
+	}
+	return marshalJSON(j)
+}
+
+// UnmarshalJSON replaces definition of update builder by given JSON.
+func (u *updateBuilder) UnmarshalJSON(data []byte) error {
+	var j updateJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Type != "update" {
+		return fmt.Errorf("expected update builder, got '%s'", j.Type)
+	}
+
+	*u = *UpdateBuilder()
+	u.table = j.Table
+	u.fields = j.Columns
+	for _, cc := range j.Calc {
+		u.calcfields = append(u.calcfields, calcColumn{cc.Column, cc.Value})
+	}
+	for _, cc := range j.Case {
+		u.casefields = append(u.casefields, caseColumn{cc.Column, cc.Case})
+	}
+	var err error
+	if u.conditionGroups, err = whereGroups(j.Where); err != nil {
+		return err
+	}
+	u.returningFields = j.Returning
+	return nil
+}
+
+// MarshalJSON returns JSON of delete builder.
+func (u *deleteBuilder) MarshalJSON() ([]byte, error) {
+	return marshalJSON(deleteJSON{"delete", u.table, u.whereJSON(), u.returningFields})
+}
+
+// UnmarshalJSON replaces definition of delete builder by given JSON.
+func (u *deleteBuilder) UnmarshalJSON(data []byte) error {
+	var j deleteJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Type != "delete" {
+		return fmt.Errorf("expected delete builder, got '%s'", j.Type)
+	}
+
+	*u = *DeleteBuilder()
+	u.table = j.Table
+	var err error
+	if u.conditionGroups, err = whereGroups(j.Where); err != nil {
+		return err
+	}
+	u.returningFields = j.Returning
+	return nil
+}
+
+// MarshalJSON returns JSON of proc builder.
+func (s *procBuilder) MarshalJSON() ([]byte, error) {
+	j := procJSON{
+		Type:       "proc",
+		Proc:       s.proc,
+		Select:     s.selectsql,
+		NamedArgs:  s.namedArgs,
+		Alias:      s.alias,
+		ColumnDefs: s.columnDefs,
+		Where:      s.whereJSON(),
+		OrderBy:    s.orderBy,
+		Limit:      s.limitRows,
+		RowCount:   s.rowcount,
+		ReadOnly:   s.readonly,
+	}
+	if s.call {
+		j.Mode = "call"
+	} else if s.perform {
+		j.Mode = "perform"
+	}
+	for _, arg := range s.args {
+		j.Args = append(j.Args, argJSON{arg.name, directionNames[arg.direction]})
+	}
+	return marshalJSON(j)
+}
+
+// UnmarshalJSON replaces definition of proc builder by given JSON.
+func (s *procBuilder) UnmarshalJSON(data []byte) error {
+	var j procJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Type != "proc" {
+		return fmt.Errorf("expected proc builder, got '%s'", j.Type)
+	}
+
+	*s = *ProcBuilder()
+	s.proc = j.Proc
+	switch j.Mode {
+	case "":
+	case "call":
+		s.perform, s.call = true, true
+	case "perform":
+		s.perform = true
+	default:
+		return fmt.Errorf("unknown proc mode '%s'", j.Mode)
+	}
+	s.selectsql = j.Select
+	for _, arg := range j.Args {
+		direction, err := parseName(directionNames, arg.Direction, "argument direction")
+		if err != nil {
+			return err
+		}
+		s.args = append(s.args, procArg{arg.Name, argDirection(direction)})
+	}
+	s.namedArgs = j.NamedArgs
+	s.alias = j.Alias
+	s.columnDefs = j.ColumnDefs
+	var err error
+	if s.conditionGroups, err = whereGroups(j.Where); err != nil {
+		return err
+	}
+	s.orderBy = j.OrderBy
+	s.limitRows = j.Limit
+	s.rowcount = j.RowCount
+	s.readonly = j.ReadOnly
+	return nil
+}
+
+// MarshalJSON returns JSON of condition.
+func (c Condition) MarshalJSON() ([]byte, error) {
+	if c.op == "" {
+		return nil, fmt.Errorf("condition on '%s' is not created by C()", c.fieldname)
+	}
+	j := conditionJSON{Field: c.fieldname, Op: c.op, Ints: c.ints, Floats: c.floats, PgArray: c.pgArray, Sub: c.subBuilder}
+	if len(c.values) == 1 && c.op != "in" {
+		j.Value = c.values[0]
+	} else {
+		j.Values = c.values
+	}
+	return marshalJSON(j)
+}
+
+// UnmarshalJSON replaces condition by given JSON.
+func (c *Condition) UnmarshalJSON(data []byte) error {
+	var j conditionJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	cond, err := j.condition()
+	if err != nil {
+		return err
+	}
+	*c = cond
+	return nil
+}
+
+// condition creates condition of JSON as its constructor would do
+func (j *conditionJSON) condition() (Condition, error) {
+	if j.Field == "" {
+		return Condition{}, errors.New("condition requires field")
+	}
+	if j.Sub != nil {
+		switch j.Op {
+		case "=":
+			return C().EQSub(j.Field, j.Sub), nil
+		case "!=":
+			return C().NEQSub(j.Field, j.Sub), nil
+		case ">":
+			return C().GTSub(j.Field, j.Sub), nil
+		case ">=":
+			return C().GTESub(j.Field, j.Sub), nil
+		case "<":
+			return C().LTSub(j.Field, j.Sub), nil
+		case "<=":
+			return C().LTESub(j.Field, j.Sub), nil
+		case "in":
+			return C().INSub(j.Field, j.Sub), nil
+		case "not in":
+			return C().NINSub(j.Field, j.Sub), nil
+		}
+		return Condition{}, fmt.Errorf("operator '%s' of field '%s' cannot be used with sub", j.Op, j.Field)
+	}
+
+	switch j.Op {
+	case "=", "!=", ">", ">=", "<", "<=":
+		if j.Value == "" {
+			return Condition{}, fmt.Errorf("operator '%s' of field '%s' requires value or sub", j.Op, j.Field)
+		}
+	}
+	switch j.Op {
+	case "=":
+		return C().EQ(j.Field, j.Value), nil
+	case "!=":
+		return C().NEQ(j.Field, j.Value), nil
+	case ">":
+		return C().GT(j.Field, j.Value), nil
+	case ">=":
+		return C().GTE(j.Field, j.Value), nil
+	case "<":
+		return C().LT(j.Field, j.Value), nil
+	case "<=":
+		return C().LTE(j.Field, j.Value), nil
+	case "between":
+		if len(j.Values) != 2 {
+			return Condition{}, fmt.Errorf("between of field '%s' requires two values", j.Field)
+		}
+		return C().Between(j.Field, j.Values[0], j.Values[1]), nil
+	case "in":
+		switch {
+		case j.Ints != nil && j.Floats == nil && j.Values == nil:
+			return C().INInt(j.Field, j.Ints, j.PgArray), nil
+		case j.Floats != nil && j.Ints == nil && j.Values == nil:
+			return C().INFloat(j.Field, j.Floats, j.PgArray), nil
+		case j.Values != nil && j.Ints == nil && j.Floats == nil:
+			return C().INStr(j.Field, j.Values, j.PgArray), nil
+		}
+		return Condition{}, fmt.Errorf("in of field '%s' requires one of values, ints, floats and sub", j.Field)
+	case "any":
+		return C().INAnyArray(j.Field, false), nil
+	case "not any":
+		return C().INAnyArray(j.Field, true), nil
+	}
+	return Condition{}, fmt.Errorf("unknown operator '%s' of field '%s'", j.Op, j.Field)
+}
+
+// MarshalJSON returns JSON of CASE expression.
+func (c *CaseExpr) MarshalJSON() ([]byte, error) {
+	j := caseJSON{When: make([]caseWhenJSON, 0, len(c.whens)), Else: c.elseValue}
+	for _, w := range c.whens {
+		j.When = append(j.When, caseWhenJSON{w.condition, w.value})
+	}
+	return marshalJSON(j)
+}
+
+// UnmarshalJSON replaces CASE expression by given JSON.
+func (c *CaseExpr) UnmarshalJSON(data []byte) error {
+	var j caseJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*c = CaseExpr{elseValue: j.Else}
+	for _, w := range j.When {
+		c.whens = append(c.whens, caseWhen{w.Condition, w.Value})
+	}
+	return nil
+}
+
+// MarshalJSON returns JSON of ordering.
+func (o *Ordering) MarshalJSON() ([]byte, error) {
+	return marshalJSON(orderingJSON{o.expr, o.position, o.caseExpr, o.descending, nullsNames[o.nulls], o.collation})
+}
+
+// UnmarshalJSON replaces ordering by given JSON.
+func (o *Ordering) UnmarshalJSON(data []byte) error {
+	var j orderingJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	nulls, err := parseName(nullsNames, j.Nulls, "nulls order")
+	if err != nil {
+		return err
+	}
+	*o = Ordering{j.Expr, j.Position, j.Desc, nullsOrder(nulls), j.Collation, j.Case}
+	return nil
+}
+
+// whereJSON returns condition groups in order
+func (b *builder) whereJSON() []whereJSON {
+	keys := make([]int, 0, len(b.conditionGroups))
+	for k := range b.conditionGroups {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	var groups []whereJSON
+	for _, k := range keys {
+		cg := b.conditionGroups[k]
+		groups = append(groups, whereJSON{operatorNames[cg.outer_op], operatorNames[cg.inner_op], cg.conditions})
+	}
+	return groups
+}
+
+// whereGroups returns condition groups of builder from JSON
+func whereGroups(where []whereJSON) (map[int]conditionGroup, error) {
+	groups := make(map[int]conditionGroup, len(where))
+	for i, w := range where {
+		outer, err := parseName(operatorNames, w.Op, "operator")
+		if err != nil {
+			return nil, err
+		}
+		inner, err := parseName(operatorNames, w.Inner, "operator")
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && Operator(outer) == opdefault {
+			return nil, errors.New("where group requires operator")
+		}
+		groups[i] = conditionGroup{outer_op: Operator(outer), inner_op: Operator(inner), conditions: w.Conditions}
+	}
+	return groups, nil
+}
+
+// parseName returns value of constant with given name
+func parseName(names []string, name, what string) (int, error) {
+	for i, n := range names {
+		if n == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %s '%s'", what, name)
+}
@@ -48,6 +48,9 @@ type StatementInfo struct {
 	ParamFields string
 	//ParamCount is count of total parameters in generated SQL.
 	ParamCount int
+	//OutParamFields holds name of comma separated OUTPUT or INOUT parameters of stored procedure, which return values.
+	//On MS-SQL these are also part of ParamFields, on MySQL these are session variables to be selected after call.
+	OutParamFields string
 	//ReturningFields holds name of comma separated fields returned with PostgreSQL RETURNING clause.
 	ReturningFields string
 	//SQL is generated Sql statement.
@@ -57,30 +60,44 @@ type StatementInfo struct {
 	ReadOnly bool
 }
 
+// Builder is implemented by all statement builders of gosql.
+// FileWriter accepts Builder to build its statement for each of database types, see FileWriter.QueueBuilder.
+type Builder interface {
+	Build(terminateWithSemiColon bool) StatementInfo
+	setDialect(dbtype string)
+	defaultSchema(schema *Schema)
+}
+
 type builder struct {
 	paramCounter    int
 	fieldCounter    int
 	fieldCsv        strings.Builder
 	paramCsv        strings.Builder
 	returningCsv    strings.Builder
+	outParamCsv     strings.Builder
 	conditionGroups map[int]conditionGroup
 	readonly        bool
 	paramChar       string
 	paramNumeric    bool
 	dbtype          string
+	schema          *Schema
 }
 
 // selectBuilder allow to dynamically build SQL to query database-tables
 type selectBuilder struct {
 	builder
-	selectsql []selectSQL
-	fromsql   []string
-	joinsql   []string
-	groupBy   []string
-	orderBy   []string
-	limitRows int
-	tables    map[string]string
-	rowcount  bool
+	selectsql    []selectSQL
+	fromsql      []string
+	joinsql      []joinSQL
+	groupBy      []string
+	orderBy      []*Ordering
+	limitRows    int
+	tables       map[string]fromSQL
+	rowcount     bool
+	distinct     bool
+	distinctOn   []string
+	grouping     groupingType
+	groupingSets [][]string
 }
 
 // insertBuilder allow to dynamically build SQL to insert record in database
@@ -96,10 +113,21 @@ type updateBuilder struct {
 	builder
 	table           string
 	fields          []string
-	calcfields      map[string]string
+	calcfields      []calcColumn
+	casefields      []caseColumn
 	returningFields []string
 }
 
+type calcColumn struct {
+	col   string
+	value string
+}
+
+type caseColumn struct {
+	col  string
+	expr *CaseExpr
+}
+
 // deleteBuilder allow to dynamically build SQL to delete record from database
 type deleteBuilder struct {
 	builder
@@ -110,20 +138,45 @@ type deleteBuilder struct {
 // selectBuilder allow to dynamically build SQL to query database-tables
 type procBuilder struct {
 	builder
-	selectsql []string
-	proc      string
-	orderBy   []string
-	limitRows int
-	args      []string
-	rowcount  bool
-	perform   bool
+	selectsql  []string
+	proc       string
+	orderBy    []string
+	limitRows  int
+	args       []procArg
+	rowcount   bool
+	perform    bool
+	call       bool
+	namedArgs  bool
+	alias      string
+	columnDefs []string
+}
+
+type argDirection int
+
+const (
+	argIn argDirection = iota
+	argOut
+	argInOut
+	argExpr
+)
+
+type procArg struct {
+	name      string
+	direction argDirection
 }
 
 // initEnv parse environment variables and set database type and paramter format
 func (b *builder) initEnv() {
-	b.dbtype = os.Getenv("DATABASE_TYPE")
-	paramCharacter := os.Getenv("PARAM_CHAR")
-	paramIsNumeric := os.Getenv("PARAM_APPEND_NUMBER")
+	b.setDialect(os.Getenv("DATABASE_TYPE"))
+}
+
+// setDialect sets database type and paramter format, so the same builder can be built for other database type.
+// Nested builders follow database type of their parent when built.
+func (b *builder) setDialect(dbtype string) {
+	b.dbtype = dbtype
+	b.paramNumeric = false
+	paramCharacter := paramEnv("PARAM_CHAR", dbtype)
+	paramIsNumeric := paramEnv("PARAM_APPEND_NUMBER", dbtype)
 
 	switch b.dbtype {
 	case DbTypePostgreSQL:
@@ -157,6 +210,43 @@ func (b *builder) initEnv() {
 	}
 }
 
+// paramEnv returns environment variable of parameter format for database type, i.e. the variable suffixed by
+// database type like PARAM_CHAR_MSSQL, or else the variable itself if database type is the one set by DATABASE_TYPE.
+// So the format set for DATABASE_TYPE does not leak into other database types when building for several of them.
+func paramEnv(name, dbtype string) string {
+	if dbtype != DbTypePostgreSQL && dbtype != DbTypeMsSQL {
+		dbtype = DbTypeMySQL
+	}
+	if v, ok := os.LookupEnv(name + "_" + strings.ToUpper(dbtype)); ok {
+		return v
+	}
+	envType := os.Getenv("DATABASE_TYPE")
+	if envType != DbTypePostgreSQL && envType != DbTypeMsSQL {
+		envType = DbTypeMySQL
+	}
+	if envType != dbtype {
+		return ""
+	}
+	return os.Getenv(name)
+}
+
+// inheritDialect sets database type and paramter format same as parent, used before building nested builders
+func (b *builder) inheritDialect(parent *builder) {
+	b.dbtype = parent.dbtype
+	b.paramChar = parent.paramChar
+	b.paramNumeric = parent.paramNumeric
+}
+
+// reset clears counters and meta information of previous build, so builder can be built again
+func (b *builder) reset(startParam int) {
+	b.paramCounter = startParam
+	b.fieldCounter = 0
+	b.fieldCsv.Reset()
+	b.paramCsv.Reset()
+	b.returningCsv.Reset()
+	b.outParamCsv.Reset()
+}
+
 func (b *builder) addFieldToCSV(fld string) {
 	if fld == "" {
 		return
@@ -187,6 +277,13 @@ func (b *builder) addReturningCSV(fld string) {
 	b.returningCsv.WriteString(fld)
 }
 
+func (b *builder) addOutParamCSV(param string) {
+	if b.outParamCsv.Len() > 0 {
+		b.outParamCsv.Write(comma)
+	}
+	b.outParamCsv.WriteString(param)
+}
+
 // getWhereClause prepare and return where clause for given conditiongroups and number of parameters added
 func (b *builder) getWhereClause() string {
 	var sql strings.Builder
@@ -215,76 +312,72 @@ func (b *builder) getWhereClause() string {
 		}
 
 		sql.WriteString("(")
-		// sort array so fields will always be in same order
-		sort.Slice(cg.conditions, func(i, j int) bool {
-			return cg.conditions[i].GetFieldName() < cg.conditions[j].GetFieldName()
-		})
-
-		i := 0
-		for _, cond := range cg.conditions {
-			if i > 0 {
-				if cg.inner_op == OpOR {
-					sql.Write(oor)
-				} else {
-					sql.Write(and)
-				}
-			}
-
-			condSql := cond.GetSQL()
+		b.writeConditions(&sql, cg.conditions, cg.inner_op)
+		sql.WriteString(")")
+	}
 
-			if cond.GetBuilder() != nil {
-				// generate sub sql
-				subStmp := cond.GetBuilder().build(false, b.paramCounter, true)
-				// update param, paracount etc as per sub SQL
-				b.addParamToCSV(subStmp.ParamFields)
-				b.paramCounter = subStmp.ParamCount
+	return sql.String()
+}
 
-				// write sql like 'filed=(sub sql)'
-				sql.WriteString(cond.GetFieldName())
-				// here conditionsql holds operator like = or <= or > etc.
-				sql.WriteString(condSql)
-				sql.Write(openbrace)
-				sql.WriteString(subStmp.SQL)
-				sql.Write(closebrace)
+// writeConditions writes given conditions joined by innerOp to sql.
+// Parameters and sub-sqls are numbered in continuation of builder's param counter.
+func (b *builder) writeConditions(sql *strings.Builder, conditions []Condition, innerOp Operator) {
+	// sort array so fields will always be in same order
+	sort.Slice(conditions, func(i, j int) bool {
+		return conditions[i].GetFieldName() < conditions[j].GetFieldName()
+	})
 
+	i := 0
+	for _, cond := range conditions {
+		if i > 0 {
+			if innerOp == OpOR {
+				sql.Write(oor)
 			} else {
-				// replace '?' with pg param i.e $1, $2 ...
-				//
-				// if param char not already "?" and also there is no need to add numbers in param
-				// then do nothing, otherwise replace parameter format
-				if strings.Contains(condSql, "?") && b.paramChar != "?" && b.paramNumeric {
-					tmp := strings.Split(condSql, "?")
-					if len(tmp) > 1 {
-						for _, str := range tmp {
-							if len(str) < 1 {
-								continue
-							}
-							if str == ")" { // sub-sql or ANY(?) ends
-								sql.WriteString(str)
-								continue
-							}
-
-							sql.WriteString(str)
-							sql.WriteString(b.paramChar)
-							if b.paramNumeric {
-								sql.WriteString(strconv.Itoa(b.paramCounter + 1))
-							}
-
-							//add parameter to csv
-							b.addParamToCSV(cond.GetFieldName())
-						}
-					}
-				} else {
-					if len(condSql) > 0 {
-						sql.WriteString(condSql)
-					}
-				}
+				sql.Write(and)
 			}
+		}
+
+		condSql := cond.GetSQL()
 
-			i++
+		if cond.GetBuilder() != nil {
+			// generate sub sql
+			cond.GetBuilder().inheritDialect(b)
+			subStmp := cond.GetBuilder().build(false, b.paramCounter, true)
+			// update param, paracount etc as per sub SQL
+			b.addParamToCSV(subStmp.ParamFields)
+			b.paramCounter = subStmp.ParamCount
+
+			// write sql like 'filed=(sub sql)'
+			sql.WriteString(cond.GetFieldName())
+			// here conditionsql holds operator like = or <= or > etc.
+			sql.WriteString(condSql)
+			sql.Write(openbrace)
+			sql.WriteString(subStmp.SQL)
+			sql.Write(closebrace)
+
+		} else {
+			b.writeParamSQL(sql, condSql, cond.GetFieldName())
 		}
-		sql.WriteString(")")
+
+		i++
 	}
+}
 
-	return sql.String()
+// writeParamSQL writes expr to sql replacing each '?' with parameter in format of database type
+// i.e $1, $2 ... Each parameter is added to param csv with given name. '?' within quotes is kept and '??'
+// is written as '?', see splitParams.
+func (b *builder) writeParamSQL(sql *strings.Builder, expr, paramName string) {
+	parts := splitParams(expr)
+	for i, str := range parts {
+		sql.WriteString(str)
+		if i == len(parts)-1 {
+			break
+		}
+
+		sql.WriteString(b.paramChar)
+		if b.paramNumeric {
+			sql.WriteString(strconv.Itoa(b.paramCounter + 1))
+		}
+		b.addParamToCSV(paramName)
+	}
 }
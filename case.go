@@ -0,0 +1,61 @@
+package gosql
+
+import "strings"
+
+// CaseExpr holds a searched CASE expression.
+// It can be used in select-list, SET clause of UpdateBuilder and ORDER BY clause.
+type CaseExpr struct {
+	whens     []caseWhen
+	elseValue string
+}
+
+type caseWhen struct {
+	condition Condition
+	value     string
+}
+
+// Case creates a new CASE expression.
+// For example
+//
+//	Case().When(C().GT("Marks", "?"), "'pass'").Else("'fail'")
+//
+// Values may contain '?' as parameter, those are numbered along with parameters of conditions.
+func Case() *CaseExpr {
+	return &CaseExpr{}
+}
+
+// When adds 'when condition then value' branch to CASE expression.
+func (c *CaseExpr) When(cond ICondition, value string) *CaseExpr {
+	c.whens = append(c.whens, caseWhen{cond.(Condition), strings.Trim(value, " ")})
+	return c
+}
+
+// Else sets value of CASE expression when none of the conditions is true.
+func (c *CaseExpr) Else(value string) *CaseExpr {
+	c.elseValue = strings.Trim(value, " ")
+	return c
+}
+
+// writeCase writes CASE expression to sql.
+// Parameters in values are added to param csv with paramName, or with field name of first condition if paramName is empty.
+func (b *builder) writeCase(sql *strings.Builder, c *CaseExpr, paramName string) {
+	if len(c.whens) < 1 {
+		panic("case expression requires at least one when condition")
+	}
+	if paramName == "" {
+		paramName = c.whens[0].condition.GetFieldName()
+	}
+
+	sql.WriteString("case")
+	for _, w := range c.whens {
+		sql.WriteString(" when ")
+		b.writeConditions(sql, []Condition{w.condition}, OpAND)
+		sql.WriteString(" then ")
+		b.writeParamSQL(sql, w.value, paramName)
+	}
+	if c.elseValue != "" {
+		sql.WriteString(" else ")
+		b.writeParamSQL(sql, c.elseValue, paramName)
+	}
+	sql.WriteString(" end")
+}
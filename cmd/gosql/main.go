@@ -0,0 +1,56 @@
+// Command gosql generates GO code and JSON with SQL statements described by a spec file, see gosql.Spec.
+//
+// Usage:
+//
+//	gosql [-spec gosql.json] [-check]
+//
+// Spec file is read as YAML when its extension is '.yaml' or '.yml', and as JSON otherwise.
+// It can be run by go generate with a directive like
+//
+//	//go:generate gosql -spec queries.yaml
+//
+// With -check, files are not written. Differences of files on disk from generated ones are printed as unified diff,
+// and gosql exits with status 1 when there is any difference.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/samtech09/gosql"
+)
+
+func main() {
+	specFile := flag.String("spec", "gosql.json", "JSON or YAML file describing statements and output")
+	check := flag.Bool("check", false, "report stale generated files as unified diff instead of writing them")
+	flag.Parse()
+
+	if err := run(*specFile, *check); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(specFile string, check bool) error {
+	spec, err := gosql.LoadSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("%s: %s", specFile, err.Error())
+	}
+
+	dir := filepath.Dir(specFile)
+	if !check {
+		return spec.Write(dir)
+	}
+
+	diff, err := spec.Check(dir)
+	if err != nil {
+		return err
+	}
+	if diff != "" {
+		fmt.Print(diff)
+		return fmt.Errorf("generated files are stale, run gosql -spec %s", specFile)
+	}
+	return nil
+}
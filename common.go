@@ -2,10 +2,22 @@ package gosql
 
 import (
 	"encoding/json"
+	"fmt"
+	"go/token"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
+//commonInitialisms are written in upper case in GO identifiers, as suggested by golint
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "GUID": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true, "QPS": true,
+	"RAM": true, "RHS": true, "RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true, "URL": true,
+	"UTF8": true, "VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
 //sliceToStringInt convert slice to int to comma separated string
 func sliceToStringInt(a []int, sep string) string {
 	if len(a) == 0 {
@@ -32,6 +44,148 @@ func sliceToStringFloat(a []float64, sep string) string {
 	return strings.Join(b, sep)
 }
 
+//trimAll trims spaces around each string of slice
+func trimAll(a []string) []string {
+	b := make([]string, len(a))
+	for i, v := range a {
+		b[i] = strings.Trim(v, " ")
+	}
+	return b
+}
+
+//splitCSV splits comma separated list of names made by builders, commas within parenthesis are not treated as separator
+func splitCSV(csv string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i := 0; i < len(csv); i++ {
+		switch csv[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.Trim(csv[start:i], " "))
+				start = i + 1
+			}
+		}
+	}
+	return append(items, strings.Trim(csv[start:], " "))
+}
+
+//splitParams splits expr at each '?' parameter. '?' within quoted literals and identifiers like 'a?', "a?", `a?` and
+//[a?] of MS-SQL is not a parameter, and '??' is written as literal '?' e.g. for jsonb operator of PostgreSQL.
+func splitParams(expr string) []string {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		end := byte(0)
+		switch c {
+		case '\'', '"', '`':
+			end = c
+		case '[':
+			//subscripts like arr[?] and array[?] follow an expression, identifiers do not
+			if i == 0 || !(isNameChar(expr[i-1]) || expr[i-1] == ']' || expr[i-1] == ')') {
+				end = ']'
+			}
+		case '?':
+			if i+1 < len(expr) && expr[i+1] == '?' {
+				part.WriteByte('?')
+				i++
+			} else {
+				parts = append(parts, part.String())
+				part.Reset()
+			}
+			continue
+		}
+		if end == 0 {
+			part.WriteByte(c)
+			continue
+		}
+		j := i + 1
+		for j < len(expr) && expr[j] != end {
+			j++
+		}
+		if j < len(expr) {
+			j++
+		}
+		//'' within literal ends and starts it again, so it is copied by next iteration
+		part.WriteString(expr[i:j])
+		i = j - 1
+	}
+	return append(parts, part.String())
+}
+
+//exprName returns alias of expression like 'coalesce(x, ?) as x'. Without alias it returns column before first parameter
+//of expression e.g. 'x' of 'coalesce(x, ?)' or 'x*?', so names of parameters stay free of commas and parenthesis. It returns
+//'?' when parameter has no such column e.g. of 'cast(? as int)', so its position names it, or expression itself if it
+//has no parameter.
+func exprName(expr string) string {
+	if alias := exprAlias(expr); alias != "" {
+		return alias
+	}
+	parts := splitParams(expr)
+	if len(parts) == 1 {
+		return expr
+	}
+	before := parts[0]
+	end := len(before)
+	for end > 0 && strings.IndexByte(" ,+-*/%<>=!|&^~", before[end-1]) >= 0 {
+		end--
+	}
+	start := end
+	for start > 0 && (isNameChar(before[start-1]) || before[start-1] == '.') {
+		start--
+	}
+	if start < end {
+		return before[start:end]
+	}
+	return "?"
+}
+
+//exprAlias returns alias of expression like 'x*? as weight', or empty string if it has none. Alias is accepted only
+//outside of parenthesis and quotes, and only if it is an identifier.
+func exprAlias(expr string) string {
+	depth, at := 0, -1
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '\'', '"', '`':
+			if j := strings.IndexByte(expr[i+1:], c); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(expr)
+			}
+		case ' ':
+			if depth == 0 && i+4 <= len(expr) && strings.EqualFold(expr[i:i+4], " as ") {
+				at = i + 4
+			}
+		}
+	}
+	if at < 0 {
+		return ""
+	}
+	alias := strings.Trim(expr[at:], " ")
+	if alias == "" || alias[0] >= '0' && alias[0] <= '9' {
+		return ""
+	}
+	for i := 0; i < len(alias); i++ {
+		if !isNameChar(alias[i]) {
+			return ""
+		}
+	}
+	return alias
+}
+
+//isNameChar tells whether c can be part of SQL identifier
+func isNameChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
 func concat(args ...string) string {
 	var b strings.Builder
 	for _, s := range args {
@@ -48,3 +202,33 @@ func structToJSONString(d interface{}) (string, error) {
 	jsonstring := string(data[:])
 	return jsonstring, nil
 }
+
+func concatBytes(args ...string) []byte {
+	return []byte(concat(args...))
+}
+
+//toIdentifier converts name like 'user_id', 'list-for dd' or 'listForDD' to exported GO identifier i.e. 'UserID', 'ListForDD'.
+//Underscore, dash, dot and space separate words, case of letters within a word is kept except its first letter.
+//It returns error if name cannot be converted to valid identifier.
+func toIdentifier(name string) (string, error) {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.' || unicode.IsSpace(r)
+	})
+
+	var b strings.Builder
+	for _, w := range words {
+		if commonInitialisms[strings.ToUpper(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+
+	ident := b.String()
+	if !token.IsIdentifier(ident) || !token.IsExported(ident) {
+		return "", fmt.Errorf("'%s' cannot be used as GO identifier", name)
+	}
+	return ident, nil
+}
@@ -22,6 +22,12 @@ type Condition struct {
 	fieldname    string
 	conditionsql string
 	subBuilder   *selectBuilder // for sub-sql builing
+	// operator and operands the condition was created with, kept for JSON
+	op      string
+	values  []string
+	ints    []int
+	floats  []float64
+	pgArray bool
 }
 
 // C creates a new Condition
@@ -53,6 +59,8 @@ func (c Condition) GetBuilder() *selectBuilder {
 // EQ generate sql with '=' operator.
 func (c *Condition) EQ(col1, col2 string) Condition {
 	c.fieldname = col1
+	c.op = "="
+	c.values = []string{col2}
 	c.conditionsql = concat(col1, "=", col2)
 	return *c
 }
@@ -60,6 +68,8 @@ func (c *Condition) EQ(col1, col2 string) Condition {
 // NEQ generate sql with '!=' operator.
 func (c *Condition) NEQ(col1, col2 string) Condition {
 	c.fieldname = col1
+	c.op = "!="
+	c.values = []string{col2}
 	c.conditionsql = concat(col1, "!=", col2)
 	return *c
 }
@@ -67,6 +77,8 @@ func (c *Condition) NEQ(col1, col2 string) Condition {
 // GT generate sql with '>' operator.
 func (c *Condition) GT(col1, col2 string) Condition {
 	c.fieldname = col1
+	c.op = ">"
+	c.values = []string{col2}
 	c.conditionsql = concat(col1, ">", col2)
 	return *c
 }
@@ -74,6 +86,8 @@ func (c *Condition) GT(col1, col2 string) Condition {
 // GTE generate sql with '>=' operator.
 func (c *Condition) GTE(col1, col2 string) Condition {
 	c.fieldname = col1
+	c.op = ">="
+	c.values = []string{col2}
 	c.conditionsql = concat(col1, ">=", col2)
 	return *c
 }
@@ -81,6 +95,8 @@ func (c *Condition) GTE(col1, col2 string) Condition {
 // LT generate sql with '<' operator.
 func (c *Condition) LT(col1, col2 string) Condition {
 	c.fieldname = col1
+	c.op = "<"
+	c.values = []string{col2}
 	c.conditionsql = concat(col1, "<", col2)
 	return *c
 }
@@ -88,6 +104,8 @@ func (c *Condition) LT(col1, col2 string) Condition {
 // LTE generate sql with '<=' operator.
 func (c *Condition) LTE(col1, col2 string) Condition {
 	c.fieldname = col1
+	c.op = "<="
+	c.values = []string{col2}
 	c.conditionsql = concat(col1, "<=", col2)
 	return *c
 }
@@ -95,6 +113,8 @@ func (c *Condition) LTE(col1, col2 string) Condition {
 // Between generate sql with 'between clause'.
 func (c *Condition) Between(col1, col2, col3 string) Condition {
 	c.fieldname = col1
+	c.op = "between"
+	c.values = []string{col2, col3}
 	c.conditionsql = concat(col1, " between ", col2, " and ", col3)
 	return *c
 }
@@ -108,6 +128,7 @@ func (c *Condition) Between(col1, col2, col3 string) Condition {
 // EQSub generate sql with '=' operator along with SubSQL.
 func (c *Condition) EQSub(col1 string, builder *selectBuilder) Condition {
 	c.fieldname = col1
+	c.op = "="
 	c.subBuilder = builder
 	c.conditionsql = "="
 	return *c
@@ -116,6 +137,7 @@ func (c *Condition) EQSub(col1 string, builder *selectBuilder) Condition {
 // NEQSub generate sql with '!=' operator along with SubSQL.
 func (c *Condition) NEQSub(col1 string, builder *selectBuilder) Condition {
 	c.fieldname = col1
+	c.op = "!="
 	c.subBuilder = builder
 	c.conditionsql = "!="
 	return *c
@@ -124,6 +146,7 @@ func (c *Condition) NEQSub(col1 string, builder *selectBuilder) Condition {
 // GTSub generate sql with '>' operator along with SubSQL.
 func (c *Condition) GTSub(col1 string, builder *selectBuilder) Condition {
 	c.fieldname = col1
+	c.op = ">"
 	c.subBuilder = builder
 	c.conditionsql = ">"
 	return *c
@@ -132,6 +155,7 @@ func (c *Condition) GTSub(col1 string, builder *selectBuilder) Condition {
 // GTESub generate sql with '>=' operator along with SubSQL.
 func (c *Condition) GTESub(col1 string, builder *selectBuilder) Condition {
 	c.fieldname = col1
+	c.op = ">="
 	c.subBuilder = builder
 	c.conditionsql = ">="
 	return *c
@@ -140,6 +164,7 @@ func (c *Condition) GTESub(col1 string, builder *selectBuilder) Condition {
 // LTSub generate sql with '<' operator along with SubSQL.
 func (c *Condition) LTSub(col1 string, builder *selectBuilder) Condition {
 	c.fieldname = col1
+	c.op = "<"
 	c.subBuilder = builder
 	c.conditionsql = "<"
 	return *c
@@ -148,6 +173,7 @@ func (c *Condition) LTSub(col1 string, builder *selectBuilder) Condition {
 // LTESub generate sql with '<=' operator along with SubSQL.
 func (c *Condition) LTESub(col1 string, builder *selectBuilder) Condition {
 	c.fieldname = col1
+	c.op = "<="
 	c.subBuilder = builder
 	c.conditionsql = "<="
 	return *c
@@ -162,6 +188,9 @@ func (c *Condition) LTESub(col1 string, builder *selectBuilder) Condition {
 // INInt create IN clause for given field and slice of int.
 func (c *Condition) INInt(col string, in []int, usePgArray bool) Condition {
 	c.fieldname = col
+	c.op = "in"
+	c.ints = in
+	c.pgArray = usePgArray
 	csv := sliceToStringInt(in, ",")
 	if usePgArray {
 		c.conditionsql = col + "=ANY('{" + csv + "}'::integer[])"
@@ -174,6 +203,9 @@ func (c *Condition) INInt(col string, in []int, usePgArray bool) Condition {
 // INFloat create IN clause for given field and slice of float64.
 func (c *Condition) INFloat(col string, in []float64, usePgArray bool) Condition {
 	c.fieldname = col
+	c.op = "in"
+	c.floats = in
+	c.pgArray = usePgArray
 	csv := sliceToStringFloat(in, ",")
 	if usePgArray {
 		c.conditionsql = col + "=ANY('{" + csv + "}'::numeric[])"
@@ -186,6 +218,9 @@ func (c *Condition) INFloat(col string, in []float64, usePgArray bool) Condition
 // INStr create IN clause for given field and slice of string.
 func (c *Condition) INStr(col string, in []string, usePgArray bool) Condition {
 	c.fieldname = col
+	c.op = "in"
+	c.values = in
+	c.pgArray = usePgArray
 	if usePgArray {
 		csv := strings.Join(in, ",")
 		c.conditionsql = col + "=ANY('{" + csv + "}'::text[])"
@@ -199,6 +234,7 @@ func (c *Condition) INStr(col string, in []string, usePgArray bool) Condition {
 // INSub create IN clause for given field with sub-sql.
 func (c *Condition) INSub(col string, builder *selectBuilder) Condition {
 	c.fieldname = col
+	c.op = "in"
 	c.subBuilder = builder
 	c.conditionsql = " IN "
 	//c.conditionsql = concat(col, " IN (", builder.Build(false), ")")
@@ -208,6 +244,7 @@ func (c *Condition) INSub(col string, builder *selectBuilder) Condition {
 // INSub create IN clause for given field with sub-sql.
 func (c *Condition) NINSub(col string, builder *selectBuilder) Condition {
 	c.fieldname = col
+	c.op = "not in"
 	c.subBuilder = builder
 	c.conditionsql = " NOT IN "
 	//c.conditionsql = concat(col, " IN (", builder.Build(false), ")")
@@ -219,8 +256,10 @@ func (c *Condition) NINSub(col string, builder *selectBuilder) Condition {
 func (c *Condition) INAnyArray(col string, notEQ bool) Condition {
 	c.fieldname = col
 	if notEQ {
+		c.op = "not any"
 		c.conditionsql = col + "!=ANY(?)"
 	} else {
+		c.op = "any"
 		c.conditionsql = col + "=ANY(?)"
 	}
 	return *c
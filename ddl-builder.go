@@ -0,0 +1,649 @@
+package gosql
+
+import (
+	"strings"
+)
+
+// columnTypes maps portable column types to types of PostgreSQL, MS-SQL and MySQL in that order.
+// Arguments of types in typeArgs e.g. varchar(50) are kept, those of other types e.g. int(11) are dropped as they
+// are not valid for every database. Types not listed here are written as given.
+var columnTypes = map[string][3]string{
+	"serial":      {"serial", "int identity(1,1)", "int auto_increment"},
+	"bigserial":   {"bigserial", "bigint identity(1,1)", "bigint auto_increment"},
+	"smallint":    {"smallint", "smallint", "smallint"},
+	"int":         {"integer", "int", "int"},
+	"integer":     {"integer", "int", "int"},
+	"bigint":      {"bigint", "bigint", "bigint"},
+	"text":        {"text", "nvarchar(max)", "text"},
+	"varchar":     {"varchar", "nvarchar", "varchar"},
+	"char":        {"char", "nchar", "char"},
+	"bool":        {"boolean", "bit", "boolean"},
+	"boolean":     {"boolean", "bit", "boolean"},
+	"real":        {"real", "real", "float"},
+	"float":       {"double precision", "float", "double"},
+	"double":      {"double precision", "float", "double"},
+	"decimal":     {"numeric", "decimal", "decimal"},
+	"numeric":     {"numeric", "decimal", "decimal"},
+	"date":        {"date", "date", "date"},
+	"time":        {"time", "time", "time"},
+	"timestamp":   {"timestamp", "datetime2", "datetime"},
+	"datetime":    {"timestamp", "datetime2", "datetime"},
+	"timestamptz": {"timestamptz", "datetimeoffset", "timestamp"},
+	"uuid":        {"uuid", "uniqueidentifier", "char(36)"},
+	"json":        {"jsonb", "nvarchar(max)", "json"},
+	"bytes":       {"bytea", "varbinary(max)", "longblob"},
+	"blob":        {"bytea", "varbinary(max)", "longblob"},
+}
+
+// typeArgs are portable column types taking length, precision or scale on all databases.
+var typeArgs = map[string]bool{
+	"varchar": true, "char": true, "decimal": true, "numeric": true,
+	"time": true, "timestamp": true, "datetime": true, "timestamptz": true,
+}
+
+// columnType returns type of column for database type.
+func columnType(dbtype, sqltype string) string {
+	base, args := sqltype, ""
+	if i := strings.IndexByte(sqltype, '('); i > 0 {
+		base, args = strings.TrimSpace(sqltype[:i]), sqltype[i:]
+	}
+	types, ok := columnTypes[strings.ToLower(base)]
+	if !ok {
+		return sqltype
+	}
+	if !typeArgs[strings.ToLower(base)] {
+		args = ""
+	}
+	switch dbtype {
+	case DbTypePostgreSQL:
+		return types[0] + args
+	case DbTypeMsSQL:
+		return types[1] + args
+	default:
+		return types[2] + args
+	}
+}
+
+// constraintName returns name of constraint or index made of prefix, table and columns e.g. df_users_active.
+func constraintName(prefix, table string, cols ...string) string {
+	var name strings.Builder
+	name.WriteString(prefix)
+	for _, s := range append([]string{table}, cols...) {
+		name.WriteByte('_')
+		for _, r := range s {
+			if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+				name.WriteRune(r)
+			} else {
+				name.WriteByte('_')
+			}
+		}
+	}
+	return name.String()
+}
+
+// unicodeLiteral returns MS-SQL unicode string literal of s.
+func unicodeLiteral(s string) string {
+	return "N'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// ColumnDef defines a column for CreateTableBuilder and AlterTableBuilder.
+type ColumnDef struct {
+	name       string
+	sqltype    string
+	notNull    bool
+	primaryKey bool
+	unique     bool
+	def        string
+	check      string
+	refTable   string
+	refColumn  string
+	onDelete   string
+}
+
+// Col creates definition of column with given name and type.
+// Portable types are mapped to type of database e.g. serial is serial on PostgreSQL, int identity(1,1) on MS-SQL
+// and int auto_increment on MySQL; text, varchar(n) and char(n) are nvarchar(max), nvarchar(n) and nchar(n) on MS-SQL;
+// bool is boolean on PostgreSQL and MySQL and bit on MS-SQL. Other types are written as given.
+func Col(name, sqltype string) *ColumnDef {
+	return &ColumnDef{name: name, sqltype: sqltype}
+}
+
+// NotNull sets column to not allow NULL.
+func (c *ColumnDef) NotNull() *ColumnDef {
+	c.notNull = true
+	return c
+}
+
+// PrimaryKey sets column as primary key of table, use PrimaryKey of CreateTableBuilder for keys of multiple columns.
+func (c *ColumnDef) PrimaryKey() *ColumnDef {
+	c.primaryKey = true
+	return c
+}
+
+// Unique adds unique constraint on column.
+func (c *ColumnDef) Unique() *ColumnDef {
+	c.unique = true
+	return c
+}
+
+// Default sets default value of column as SQL expression, e.g. '0', 'true' or 'current_timestamp'.
+// true and false are written as 1 and 0 for bit columns of MS-SQL, where default is added as constraint named df_<table>_<column>.
+func (c *ColumnDef) Default(expr string) *ColumnDef {
+	c.def = expr
+	return c
+}
+
+// Check adds check constraint on column with given SQL expression.
+func (c *ColumnDef) Check(expr string) *ColumnDef {
+	c.check = expr
+	return c
+}
+
+// References adds foreign key on column referencing column of other table.
+func (c *ColumnDef) References(table, column string) *ColumnDef {
+	c.refTable = table
+	c.refColumn = column
+	return c
+}
+
+// OnDelete sets action of foreign key when referenced row is deleted, e.g. 'cascade' or 'set null'.
+func (c *ColumnDef) OnDelete(action string) *ColumnDef {
+	c.onDelete = action
+	return c
+}
+
+// defaultSQL returns default expression of column for database type.
+func (c *ColumnDef) defaultSQL(dbtype string) string {
+	if dbtype == DbTypeMsSQL && columnType(dbtype, c.sqltype) == "bit" {
+		switch strings.ToLower(c.def) {
+		case "true":
+			return "1"
+		case "false":
+			return "0"
+		}
+	}
+	return c.def
+}
+
+// sql returns definition of column for given table without foreign key, see foreignKey.
+func (c *ColumnDef) sql(dbtype, table string) string {
+	var sql strings.Builder
+	sql.WriteString(c.name)
+	sql.Write(space)
+	sql.WriteString(columnType(dbtype, c.sqltype))
+	if c.notNull {
+		sql.WriteString(" not null")
+	}
+	if c.def != "" {
+		if dbtype == DbTypeMsSQL {
+			sql.WriteString(" constraint ")
+			sql.WriteString(constraintName("df", table, c.name))
+		}
+		sql.WriteString(" default ")
+		sql.WriteString(c.defaultSQL(dbtype))
+	}
+	if c.primaryKey {
+		sql.WriteString(" primary key")
+	}
+	if c.unique {
+		sql.WriteString(" unique")
+	}
+	if c.check != "" {
+		sql.WriteString(" check (")
+		sql.WriteString(c.check)
+		sql.WriteString(")")
+	}
+	return sql.String()
+}
+
+// foreignKey returns foreign key constraint of column, or empty string if it does not reference other table.
+// Foreign key is written as table constraint as MySQL ignores references in column definition.
+func (c *ColumnDef) foreignKey() string {
+	if c.refTable == "" {
+		return ""
+	}
+	fk := "foreign key (" + c.name + ") references " + c.refTable + " (" + c.refColumn + ")"
+	if c.onDelete != "" {
+		fk += " on delete " + c.onDelete
+	}
+	return fk
+}
+
+// ddlStatement returns StatementInfo of DDL sql.
+func ddlStatement(sql string, terminateWithSemiColon bool) StatementInfo {
+	if terminateWithSemiColon {
+		sql += string(closure)
+	}
+	return StatementInfo{SQL: sql}
+}
+
+// createTableBuilder allow to dynamically build SQL to create table
+type createTableBuilder struct {
+	builder
+	table       string
+	ifNotExists bool
+	columns     []*ColumnDef
+	primaryKey  []string
+	uniques     [][]string
+	checks      []string
+}
+
+// CreateTableBuilder creates new instance of CreateTableBuilder.
+// It allows to create CREATE TABLE sql statements.
+func CreateTableBuilder() *createTableBuilder {
+	c := createTableBuilder{}
+	c.initEnv()
+	return &c
+}
+
+// Table sets name of table to be created
+func (c *createTableBuilder) Table(tablename string) *createTableBuilder {
+	c.table = tablename
+	return c
+}
+
+// IfNotExists skips creating table if it already exists.
+// MS-SQL has no 'if not exists' for tables, so its statement checks object_id of table instead.
+func (c *createTableBuilder) IfNotExists() *createTableBuilder {
+	c.ifNotExists = true
+	return c
+}
+
+// Columns adds columns to table, see Col.
+func (c *createTableBuilder) Columns(cols ...*ColumnDef) *createTableBuilder {
+	c.columns = append(c.columns, cols...)
+	return c
+}
+
+// PrimaryKey sets primary key of table on given columns.
+func (c *createTableBuilder) PrimaryKey(cols ...string) *createTableBuilder {
+	c.primaryKey = cols
+	return c
+}
+
+// Unique adds unique constraint on given columns.
+func (c *createTableBuilder) Unique(cols ...string) *createTableBuilder {
+	c.uniques = append(c.uniques, cols)
+	return c
+}
+
+// Check adds check constraint on table with given SQL expression.
+func (c *createTableBuilder) Check(expr string) *createTableBuilder {
+	c.checks = append(c.checks, expr)
+	return c
+}
+
+// Build generates the create table sql statement
+func (c *createTableBuilder) Build(terminateWithSemiColon bool) StatementInfo {
+	if c.table == "" || len(c.columns) == 0 {
+		panic("create table requires table name and columns")
+	}
+	var sql strings.Builder
+	if c.ifNotExists && c.dbtype == DbTypeMsSQL {
+		sql.WriteString("if object_id(")
+		sql.WriteString(unicodeLiteral(c.table))
+		sql.WriteString(", N'U') is null ")
+	}
+	sql.WriteString("create table ")
+	if c.ifNotExists && c.dbtype != DbTypeMsSQL {
+		sql.WriteString("if not exists ")
+	}
+	sql.WriteString(c.table)
+	sql.WriteString(" (")
+
+	defs := make([]string, 0, len(c.columns)+len(c.uniques)+len(c.checks)+1)
+	for _, col := range c.columns {
+		defs = append(defs, col.sql(c.dbtype, c.table))
+	}
+	if len(c.primaryKey) > 0 {
+		defs = append(defs, "primary key ("+strings.Join(c.primaryKey, ", ")+")")
+	}
+	for _, cols := range c.uniques {
+		defs = append(defs, "unique ("+strings.Join(cols, ", ")+")")
+	}
+	for _, col := range c.columns {
+		if fk := col.foreignKey(); fk != "" {
+			defs = append(defs, fk)
+		}
+	}
+	for _, expr := range c.checks {
+		defs = append(defs, "check ("+expr+")")
+	}
+	sql.WriteString(strings.Join(defs, ", "))
+	sql.WriteString(")")
+
+	return ddlStatement(sql.String(), terminateWithSemiColon)
+}
+
+type alterKind int
+
+const (
+	alterAdd alterKind = iota
+	alterDrop
+	alterColumn
+	alterSetDefault
+	alterDropDefault
+	alterRenameColumn
+	alterRename
+)
+
+type alterAction struct {
+	kind   alterKind
+	column *ColumnDef
+	name   string
+	value  string
+}
+
+// alterTableBuilder allow to dynamically build SQL to alter table
+type alterTableBuilder struct {
+	builder
+	table   string
+	actions []alterAction
+}
+
+// AlterTableBuilder creates new instance of AlterTableBuilder.
+// It allows to create ALTER TABLE sql statements.
+func AlterTableBuilder() *alterTableBuilder {
+	a := alterTableBuilder{}
+	a.initEnv()
+	return &a
+}
+
+// Table sets name of table to be altered
+func (a *alterTableBuilder) Table(tablename string) *alterTableBuilder {
+	a.table = tablename
+	return a
+}
+
+// AddColumn adds given columns to table, see Col.
+func (a *alterTableBuilder) AddColumn(cols ...*ColumnDef) *alterTableBuilder {
+	for _, col := range cols {
+		a.actions = append(a.actions, alterAction{kind: alterAdd, column: col})
+	}
+	return a
+}
+
+// DropColumn drops given columns from table.
+func (a *alterTableBuilder) DropColumn(cols ...string) *alterTableBuilder {
+	for _, col := range cols {
+		a.actions = append(a.actions, alterAction{kind: alterDrop, name: col})
+	}
+	return a
+}
+
+// AlterColumn changes type and nullability of column, use SetDefault and DropDefault to change its default.
+// MySQL redefines whole column, so default of column should be given too.
+// MS-SQL allows only one column to be altered by a statement.
+func (a *alterTableBuilder) AlterColumn(col *ColumnDef) *alterTableBuilder {
+	a.actions = append(a.actions, alterAction{kind: alterColumn, column: col})
+	return a
+}
+
+// SetDefault sets default value of column as SQL expression.
+func (a *alterTableBuilder) SetDefault(col, expr string) *alterTableBuilder {
+	a.actions = append(a.actions, alterAction{kind: alterSetDefault, name: col, value: expr})
+	return a
+}
+
+// DropDefault drops default value of column. On MS-SQL it drops constraint named df_<table>_<column> as created by gosql.
+func (a *alterTableBuilder) DropDefault(col string) *alterTableBuilder {
+	a.actions = append(a.actions, alterAction{kind: alterDropDefault, name: col})
+	return a
+}
+
+// RenameColumn renames column of table, it can not be combined with other actions.
+func (a *alterTableBuilder) RenameColumn(col, newName string) *alterTableBuilder {
+	a.actions = append(a.actions, alterAction{kind: alterRenameColumn, name: col, value: newName})
+	return a
+}
+
+// RenameTo renames table, it can not be combined with other actions.
+func (a *alterTableBuilder) RenameTo(newName string) *alterTableBuilder {
+	a.actions = append(a.actions, alterAction{kind: alterRename, value: newName})
+	return a
+}
+
+// Build generates the alter table sql statement
+func (a *alterTableBuilder) Build(terminateWithSemiColon bool) StatementInfo {
+	if a.table == "" || len(a.actions) == 0 {
+		panic("alter table requires table name and actions")
+	}
+	for _, act := range a.actions {
+		if (act.kind == alterRenameColumn || act.kind == alterRename) && len(a.actions) > 1 {
+			panic("rename can not be combined with other alter table actions")
+		}
+	}
+
+	var sql string
+	if a.dbtype == DbTypeMsSQL {
+		sql = a.buildMsSQL()
+	} else {
+		actions := make([]string, 0, len(a.actions))
+		for _, act := range a.actions {
+			actions = append(actions, a.action(act)...)
+		}
+		sql = "alter table " + a.table + " " + strings.Join(actions, ", ")
+	}
+	return ddlStatement(sql, terminateWithSemiColon)
+}
+
+// action returns clauses of alter table action for PostgreSQL and MySQL.
+func (a *alterTableBuilder) action(act alterAction) []string {
+	switch act.kind {
+	case alterAdd:
+		clauses := []string{"add column " + act.column.sql(a.dbtype, a.table)}
+		if fk := act.column.foreignKey(); fk != "" {
+			clauses = append(clauses, "add "+fk)
+		}
+		return clauses
+	case alterDrop:
+		return []string{"drop column " + act.name}
+	case alterColumn:
+		col := act.column
+		if a.dbtype == DbTypeMySQL {
+			return []string{"modify column " + col.sql(a.dbtype, a.table)}
+		}
+		nullability := "drop not null"
+		if col.notNull {
+			nullability = "set not null"
+		}
+		return []string{
+			"alter column " + col.name + " type " + columnType(a.dbtype, col.sqltype),
+			"alter column " + col.name + " " + nullability,
+		}
+	case alterSetDefault:
+		return []string{"alter column " + act.name + " set default " + act.value}
+	case alterDropDefault:
+		return []string{"alter column " + act.name + " drop default"}
+	case alterRenameColumn:
+		return []string{"rename column " + act.name + " to " + act.value}
+	default:
+		return []string{"rename to " + act.value}
+	}
+}
+
+// buildMsSQL returns alter table statement for MS-SQL, which allows either adds or drops or a single alter column in a statement.
+func (a *alterTableBuilder) buildMsSQL() string {
+	var adds, drops []string
+	for _, act := range a.actions {
+		switch act.kind {
+		case alterAdd:
+			adds = append(adds, act.column.sql(a.dbtype, a.table))
+			if fk := act.column.foreignKey(); fk != "" {
+				adds = append(adds, fk)
+			}
+		case alterSetDefault:
+			adds = append(adds, "constraint "+constraintName("df", a.table, act.name)+" default "+act.value+" for "+act.name)
+		case alterDrop:
+			drops = append(drops, "column "+act.name)
+		case alterDropDefault:
+			drops = append(drops, "constraint "+constraintName("df", a.table, act.name))
+		case alterColumn:
+			if len(a.actions) > 1 {
+				panic("alter column can not be combined with other alter table actions on mssql")
+			}
+			col := act.column
+			nullability := " null"
+			if col.notNull {
+				nullability = " not null"
+			}
+			return "alter table " + a.table + " alter column " + col.name + " " + columnType(a.dbtype, col.sqltype) + nullability
+		case alterRenameColumn:
+			return "exec sp_rename " + unicodeLiteral(a.table+"."+act.name) + ", " + unicodeLiteral(act.value) + ", N'COLUMN'"
+		case alterRename:
+			return "exec sp_rename " + unicodeLiteral(a.table) + ", " + unicodeLiteral(act.value)
+		}
+	}
+	if len(adds) > 0 && len(drops) > 0 {
+		panic("add and drop can not be combined in alter table on mssql")
+	}
+	if len(adds) > 0 {
+		return "alter table " + a.table + " add " + strings.Join(adds, ", ")
+	}
+	return "alter table " + a.table + " drop " + strings.Join(drops, ", ")
+}
+
+// dropTableBuilder allow to dynamically build SQL to drop tables
+type dropTableBuilder struct {
+	builder
+	tables   []string
+	ifExists bool
+	cascade  bool
+}
+
+// DropTableBuilder creates new instance of DropTableBuilder.
+// It allows to create DROP TABLE sql statements.
+func DropTableBuilder() *dropTableBuilder {
+	d := dropTableBuilder{}
+	d.initEnv()
+	return &d
+}
+
+// Table sets names of tables to be dropped
+func (d *dropTableBuilder) Table(tablenames ...string) *dropTableBuilder {
+	d.tables = append(d.tables, tablenames...)
+	return d
+}
+
+// IfExists skips dropping tables which do not exist.
+func (d *dropTableBuilder) IfExists() *dropTableBuilder {
+	d.ifExists = true
+	return d
+}
+
+// Cascade drops objects depending on tables too, it is not applicable to mssql and ignored by mysql.
+func (d *dropTableBuilder) Cascade() *dropTableBuilder {
+	d.cascade = true
+	return d
+}
+
+// Build generates the drop table sql statement
+func (d *dropTableBuilder) Build(terminateWithSemiColon bool) StatementInfo {
+	if len(d.tables) == 0 {
+		panic("drop table requires table name")
+	}
+	if d.cascade && d.dbtype == DbTypeMsSQL {
+		panic("cascade is not applicable to mssql")
+	}
+	var sql strings.Builder
+	sql.WriteString("drop table ")
+	if d.ifExists {
+		sql.WriteString("if exists ")
+	}
+	sql.WriteString(strings.Join(d.tables, ", "))
+	if d.cascade {
+		sql.WriteString(" cascade")
+	}
+	return ddlStatement(sql.String(), terminateWithSemiColon)
+}
+
+// createIndexBuilder allow to dynamically build SQL to create index
+type createIndexBuilder struct {
+	builder
+	name        string
+	table       string
+	columns     []string
+	unique      bool
+	ifNotExists bool
+}
+
+// CreateIndexBuilder creates new instance of CreateIndexBuilder.
+// It allows to create CREATE INDEX sql statements.
+func CreateIndexBuilder() *createIndexBuilder {
+	c := createIndexBuilder{}
+	c.initEnv()
+	return &c
+}
+
+// Name sets name of index, it defaults to ix_<table>_<columns>.
+func (c *createIndexBuilder) Name(name string) *createIndexBuilder {
+	c.name = name
+	return c
+}
+
+// On sets table and columns of index, a column may be followed by asc or desc e.g. 'created desc'.
+func (c *createIndexBuilder) On(tablename string, cols ...string) *createIndexBuilder {
+	c.table = tablename
+	c.columns = cols
+	return c
+}
+
+// Unique creates unique index.
+func (c *createIndexBuilder) Unique() *createIndexBuilder {
+	c.unique = true
+	return c
+}
+
+// IfNotExists skips creating index if it already exists, it is not applicable to mysql.
+// MS-SQL has no 'if not exists' for indexes, so its statement checks sys.indexes instead.
+func (c *createIndexBuilder) IfNotExists() *createIndexBuilder {
+	c.ifNotExists = true
+	return c
+}
+
+// Build generates the create index sql statement
+func (c *createIndexBuilder) Build(terminateWithSemiColon bool) StatementInfo {
+	if c.table == "" || len(c.columns) == 0 {
+		panic("create index requires table name and columns")
+	}
+	for _, col := range c.columns {
+		if strings.TrimSpace(col) == "" {
+			panic("create index requires table name and columns")
+		}
+	}
+	if c.ifNotExists && c.dbtype == DbTypeMySQL {
+		panic("if not exists of index is not applicable to mysql")
+	}
+	name := c.name
+	if name == "" {
+		cols := make([]string, len(c.columns))
+		for i, col := range c.columns {
+			cols[i] = strings.Fields(col)[0]
+		}
+		name = constraintName("ix", c.table, cols...)
+	}
+
+	var sql strings.Builder
+	if c.ifNotExists && c.dbtype == DbTypeMsSQL {
+		sql.WriteString("if not exists (select * from sys.indexes where name = ")
+		sql.WriteString(unicodeLiteral(name))
+		sql.WriteString(" and object_id = object_id(")
+		sql.WriteString(unicodeLiteral(c.table))
+		sql.WriteString(")) ")
+	}
+	sql.WriteString("create ")
+	if c.unique {
+		sql.WriteString("unique ")
+	}
+	sql.WriteString("index ")
+	if c.ifNotExists && c.dbtype == DbTypePostgreSQL {
+		sql.WriteString("if not exists ")
+	}
+	sql.WriteString(name)
+	sql.WriteString(" on ")
+	sql.WriteString(c.table)
+	sql.WriteString(" (")
+	sql.WriteString(strings.Join(c.columns, ", "))
+	sql.WriteString(")")
+	return ddlStatement(sql.String(), terminateWithSemiColon)
+}
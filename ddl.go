@@ -0,0 +1,1558 @@
+package gosql
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ParseDDL creates Schema from SQL DDL statements, see Schema.ApplyDDL for supported statements.
+func ParseDDL(r io.Reader) (*Schema, error) {
+	s := NewSchema()
+	if err := s.ApplyDDL(r); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadDDL creates Schema from SQL DDL files, so migrations of a project can be used without connecting to database.
+// Each path is a file, or a folder whose '.sql' files are applied in order of their names.
+// Files named '*.down.sql' in folders are skipped, as they revert migrations.
+func LoadDDL(paths ...string) (*Schema, error) {
+	s := NewSchema()
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{p}
+		if info.IsDir() {
+			if files, err = ddlFiles(p); err != nil {
+				return nil, err
+			}
+		}
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.ApplyDDL(bytes.NewReader(data)); err != nil {
+				return nil, fmt.Errorf("%s: %s", f, err.Error())
+			}
+		}
+	}
+	return s, nil
+}
+
+// ddlFiles returns '.sql' files of folder sorted by name, except '*.down.sql'
+func ddlFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := strings.ToLower(e.Name())
+		if e.IsDir() || !strings.HasSuffix(name, ".sql") || strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, e.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ApplyDDL applies SQL DDL statements to schema in order, statements are separated by ';' or by 'GO' lines of MS-SQL.
+//
+// Supported statements are CREATE TABLE (including AS SELECT, LIKE and PARTITION OF), ALTER TABLE, DROP TABLE,
+// CREATE VIEW, ALTER VIEW, DROP VIEW, RENAME TABLE of MySQL and sp_rename of MS-SQL, as written for PostgreSQL,
+// MySQL and MS-SQL. Columns of views are derived from their select-list. Primary keys, unique keys, checks and
+// foreign keys are kept whether declared with a column or with the table. Other statements are skipped, incomplete
+// statements like a DEFAULT without value or a view without columns return an error.
+func (s *Schema) ApplyDDL(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	toks, err := lexDDL(string(data))
+	if err != nil {
+		return err
+	}
+
+	start := 0
+	for i := 0; i <= len(toks); i++ {
+		if i < len(toks) && !toks[i].is(";") {
+			continue
+		}
+		if i > start {
+			p := ddlParser{schema: s, toks: toks[start:i]}
+			if err := p.statement(); err != nil {
+				return err
+			}
+		}
+		start = i + 1
+	}
+	return nil
+}
+
+// token kinds of DDL
+const (
+	tokWord   = 'w' // keyword or unquoted identifier
+	tokIdent  = 'i' // quoted identifier
+	tokString = 's' // string literal
+	tokNumber = 'n'
+	tokPunct  = 'p'
+	tokBody   = 'b' // dollar quoted body of PostgreSQL
+)
+
+type ddlToken struct {
+	kind byte
+	text string
+	line int
+}
+
+// is tells whether token is given keyword or punctuation, case-insensitive
+func (t ddlToken) is(word string) bool {
+	return (t.kind == tokWord || t.kind == tokPunct) && strings.EqualFold(t.text, word)
+}
+
+// isName tells whether token can be name of table or column
+func (t ddlToken) isName() bool {
+	return t.kind == tokWord || t.kind == tokIdent
+}
+
+// lexDDL splits SQL into tokens, comments are dropped and 'GO' lines are returned as ';'
+func lexDDL(sql string) ([]ddlToken, error) {
+	var toks []ddlToken
+	line := 1
+	lineStart := true
+	for i := 0; i < len(sql); {
+		c := sql[i]
+
+		if lineStart {
+			lineStart = false
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			if isBatchSeparator(sql[i : i+end]) {
+				toks = append(toks, ddlToken{tokPunct, ";", line})
+				i += end
+				continue
+			}
+		}
+
+		switch {
+		case c == '\n':
+			line++
+			lineStart = true
+			i++
+
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+
+		case strings.HasPrefix(sql[i:], "--") || (c == '#' && (i+1 == len(sql) || !isWordByte(sql[i+1]))):
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+
+		case strings.HasPrefix(sql[i:], "/*"):
+			depth := 0
+			startLine := line
+			for ; i < len(sql); i++ {
+				if strings.HasPrefix(sql[i:], "/*") {
+					depth++
+					i++
+				} else if strings.HasPrefix(sql[i:], "*/") {
+					depth--
+					i++
+					if depth == 0 {
+						i++
+						break
+					}
+				} else if sql[i] == '\n' {
+					line++
+				}
+			}
+			if depth > 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", startLine)
+			}
+
+		case c == '\'' || (i+1 < len(sql) && sql[i+1] == '\'' && strings.IndexByte("nNeExXbB", c) >= 0):
+			escapes := c == 'e' || c == 'E'
+			start := i
+			if c != '\'' {
+				i++
+			}
+			_, n, lines, ok := quotedText(sql[i:], '\'', escapes)
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			toks = append(toks, ddlToken{tokString, sql[start : i+n], line})
+			i += n
+			line += lines
+
+		case c == '"' || c == '`' || (c == '[' && (i == 0 || !isWordByte(sql[i-1]))):
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			text, n, lines, ok := quotedText(sql[i:], closing, false)
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated identifier", line)
+			}
+			toks = append(toks, ddlToken{tokIdent, text, line})
+			i += n
+			line += lines
+
+		case c == '$' && dollarTag(sql[i:]) != "":
+			tag := dollarTag(sql[i:])
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated %s body", line, tag)
+			}
+			body := sql[i : i+len(tag)+end+len(tag)]
+			toks = append(toks, ddlToken{tokBody, body, line})
+			line += strings.Count(body, "\n")
+			i += len(body)
+
+		case c >= '0' && c <= '9' || (c == '.' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9'):
+			start := i
+			for i < len(sql) && (sql[i] >= '0' && sql[i] <= '9' || sql[i] == '.') {
+				i++
+			}
+			if i+1 < len(sql) && (sql[i] == 'e' || sql[i] == 'E') {
+				j := i + 1
+				if sql[j] == '-' || sql[j] == '+' {
+					j++
+				}
+				if j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+					for i = j; i < len(sql) && sql[i] >= '0' && sql[i] <= '9'; i++ {
+					}
+				}
+			}
+			toks = append(toks, ddlToken{tokNumber, sql[start:i], line})
+
+		case isWordByte(c):
+			start := i
+			for i < len(sql) && isWordByte(sql[i]) {
+				i++
+			}
+			toks = append(toks, ddlToken{tokWord, sql[start:i], line})
+
+		case strings.HasPrefix(sql[i:], "::"):
+			toks = append(toks, ddlToken{tokPunct, "::", line})
+			i += 2
+
+		default:
+			toks = append(toks, ddlToken{tokPunct, string(c), line})
+			i++
+		}
+	}
+	return toks, nil
+}
+
+// isBatchSeparator tells whether line is 'GO' batch separator of MS-SQL, optionally with count
+func isBatchSeparator(line string) bool {
+	f := strings.Fields(line)
+	if len(f) == 0 || len(f) > 2 || !strings.EqualFold(f[0], "go") {
+		return false
+	}
+	if len(f) == 2 {
+		for _, r := range f[1] {
+			if !unicode.IsDigit(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '@' || c == '#' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' || c >= 0x80
+}
+
+// quotedText returns text quoted by s[0] up to closing quote, doubled closing quote is unescaped.
+// It also returns count of bytes consumed and of newlines in text.
+func quotedText(s string, closing byte, escapes bool) (string, int, int, bool) {
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escapes && s[i] == '\\' && i+1 < len(s):
+			i++
+			sb.WriteByte(s[i])
+		case s[i] == closing && i+1 < len(s) && s[i+1] == closing:
+			sb.WriteByte(closing)
+			i++
+		case s[i] == closing:
+			text := sb.String()
+			return text, i + 1, strings.Count(s[:i], "\n"), true
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return "", 0, 0, false
+}
+
+// dollarTag returns opening tag like '$$' or '$body$' at start of s, or empty string
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '$' {
+			return s[:i+1]
+		}
+		if !(s[i] == '_' || s[i] >= 'a' && s[i] <= 'z' || s[i] >= 'A' && s[i] <= 'Z' || i > 1 && s[i] >= '0' && s[i] <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+// ddlParser parses tokens of a single statement
+type ddlParser struct {
+	schema *Schema
+	toks   []ddlToken
+	pos    int
+}
+
+func (p *ddlParser) eof() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *ddlParser) peek() ddlToken {
+	if p.eof() {
+		return ddlToken{}
+	}
+	return p.toks[p.pos]
+}
+
+func (p *ddlParser) next() ddlToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// accept consumes given keywords if tokens are these in sequence
+func (p *ddlParser) accept(words ...string) bool {
+	for i, w := range words {
+		if p.pos+i >= len(p.toks) || !p.toks[p.pos+i].is(w) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *ddlParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if len(p.toks) > 0 {
+		line = p.toks[len(p.toks)-1].line
+		if !p.eof() {
+			line = p.peek().line
+		}
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// name consumes possibly qualified name and returns its last part e.g. 'users' of 'dbo.[users]'
+func (p *ddlParser) name() (string, error) {
+	if !p.peek().isName() {
+		return "", p.errorf("expected name, got '%s'", p.peek().text)
+	}
+	name := p.next().text
+	for p.peek().is(".") && p.pos+1 < len(p.toks) && p.toks[p.pos+1].isName() {
+		p.pos++
+		name = p.next().text
+	}
+	return name, nil
+}
+
+// group consumes tokens enclosed in parentheses starting at current token, and returns tokens inside
+func (p *ddlParser) group() ([]ddlToken, error) {
+	if !p.peek().is("(") {
+		return nil, p.errorf("expected '(', got '%s'", p.peek().text)
+	}
+	depth := 0
+	for i := p.pos; i < len(p.toks); i++ {
+		switch {
+		case p.toks[i].is("("):
+			depth++
+		case p.toks[i].is(")"):
+			depth--
+			if depth == 0 {
+				inner := p.toks[p.pos+1 : i]
+				p.pos = i + 1
+				return inner, nil
+			}
+		}
+	}
+	return nil, p.errorf("missing ')'")
+}
+
+// skip consumes current token, or whole group if it is '('
+func (p *ddlParser) skip() {
+	if p.peek().is("(") {
+		if _, err := p.group(); err == nil {
+			return
+		}
+	}
+	p.pos++
+}
+
+// splitTokens splits tokens by commas outside of parentheses
+func splitTokens(toks []ddlToken) [][]ddlToken {
+	var parts [][]ddlToken
+	depth, start := 0, 0
+	for i, t := range toks {
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+		case t.is(",") && depth == 0:
+			parts = append(parts, toks[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(toks) {
+		parts = append(parts, toks[start:])
+	}
+	return parts
+}
+
+// renderTokens returns SQL text of tokens, e.g. type 'numeric(5,2)' or default 'now()'
+func renderTokens(toks []ddlToken) string {
+	var sb strings.Builder
+	for i, t := range toks {
+		if i > 0 {
+			// words are separated by space, punctuation is written as is e.g. 'numeric(5,2)' or 'now()'
+			prev := toks[i-1]
+			if t.kind != tokPunct && (prev.kind != tokPunct || prev.is(")")) {
+				sb.WriteByte(' ')
+			}
+		}
+		switch t.kind {
+		case tokIdent:
+			sb.WriteString(`"` + t.text + `"`)
+		default:
+			sb.WriteString(t.text)
+		}
+	}
+	return sb.String()
+}
+
+// statement applies statement to schema
+func (p *ddlParser) statement() error {
+	// conditional statements of MS-SQL like 'IF OBJECT_ID(...) IS NULL CREATE TABLE ...' apply statement after condition
+	if p.peek().is("if") || p.peek().is("begin") {
+		depth := 0
+		for ; !p.eof(); p.pos++ {
+			t := p.peek()
+			if t.is("(") {
+				depth++
+			} else if t.is(")") {
+				depth--
+			} else if depth == 0 && p.pos > 0 && (t.is("create") || t.is("alter") || t.is("drop") || t.is("exec")) {
+				break
+			}
+		}
+	}
+
+	switch {
+	case p.accept("create"):
+		return p.create()
+	case p.accept("alter"):
+		switch {
+		case p.accept("table"):
+			return p.alterTable()
+		case p.accept("view"):
+			return p.view(false)
+		}
+	case p.accept("drop"):
+		return p.drop()
+	case p.accept("rename", "table"):
+		return p.renameTables()
+	case p.accept("exec"), p.accept("execute"), p.peek().is("sp_rename"):
+		return p.spRename()
+	}
+	return nil
+}
+
+// createModifiers are skipped between CREATE and TABLE or VIEW
+var createModifiers = map[string]bool{
+	"or": true, "replace": true, "alter": true, "temp": true, "temporary": true, "unlogged": true, "global": true,
+	"local": true, "materialized": true, "algorithm": true, "definer": true, "sql": true, "security": true,
+	"invoker": true, "merge": true, "temptable": true, "undefined": true, "=": true, "@": true,
+}
+
+func (p *ddlParser) create() error {
+	for !p.eof() {
+		t := p.peek()
+		switch {
+		case t.is("table"):
+			p.pos++
+			return p.createTable()
+		case t.is("view"):
+			p.pos++
+			return p.view(true)
+		case createModifiers[strings.ToLower(t.text)] && t.kind != tokIdent || t.kind == tokIdent || t.kind == tokString:
+			p.pos++
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *ddlParser) createTable() error {
+	p.accept("if", "not", "exists")
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	t := &Table{Name: name}
+
+	switch {
+	case p.peek().is("("):
+		elements, err := p.group()
+		if err != nil {
+			return err
+		}
+		for _, el := range splitTokens(elements) {
+			if err := p.tableElement(t, el); err != nil {
+				return err
+			}
+		}
+
+	case p.accept("like"), p.accept("partition", "of"):
+		if err := p.copyColumns(t); err != nil {
+			return err
+		}
+
+	default:
+		// CREATE TABLE ... [AS] SELECT
+		for !p.eof() && !p.peek().is("as") && !p.peek().is("select") {
+			p.skip()
+		}
+		p.accept("as")
+		if p.eof() {
+			return p.errorf("expected columns of table '%s'", name)
+		}
+		cols, err := p.selectColumns(p.toks[p.pos:])
+		if err != nil {
+			return err
+		}
+		t.Columns = cols
+	}
+
+	p.schema.Add(t)
+	return nil
+}
+
+// copyColumns copies columns of table named at current token to t
+func (p *ddlParser) copyColumns(t *Table) error {
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	src := p.schema.Lookup(name)
+	if src == nil {
+		return p.errorf("unknown table '%s'", name)
+	}
+	for _, c := range src.Columns {
+		t.AddColumn(c)
+	}
+	return nil
+}
+
+// constraintStarts are words starting table constraints
+var constraintStarts = map[string]bool{
+	"constraint": true, "primary": true, "unique": true, "foreign": true, "check": true, "key": true, "index": true,
+	"fulltext": true, "spatial": true, "exclude": true, "period": true,
+}
+
+// tableElement adds column or constraint of CREATE TABLE to t
+func (p *ddlParser) tableElement(t *Table, el []ddlToken) error {
+	if len(el) == 0 {
+		return nil
+	}
+	sub := ddlParser{schema: p.schema, toks: el}
+	if el[0].kind == tokWord && constraintStarts[strings.ToLower(el[0].text)] {
+		return sub.tableConstraint(t)
+	}
+	if el[0].is("like") {
+		sub.pos++
+		return sub.copyColumns(t)
+	}
+	c, err := sub.column(t)
+	if err != nil {
+		return err
+	}
+	t.AddColumn(c)
+	return nil
+}
+
+// tableConstraint applies constraint of CREATE TABLE or ALTER TABLE ADD to t, i.e. PRIMARY KEY, UNIQUE, CHECK,
+// FOREIGN KEY and MS-SQL 'DEFAULT x FOR col'. Indexes of MySQL and other constraints like EXCLUDE are skipped.
+func (p *ddlParser) tableConstraint(t *Table) error {
+	name, err := p.constraintName()
+	if err != nil {
+		return err
+	}
+	switch {
+	case p.accept("primary", "key"):
+		p.skipTo("(")
+		names, err := p.columnList("primary key of table '" + t.Name + "'")
+		if err != nil {
+			return err
+		}
+		return p.setPrimaryKey(t, names)
+
+	case p.accept("unique"):
+		// UNIQUE [KEY | INDEX] [name] [CLUSTERED | NONCLUSTERED] (columns)
+		if (p.accept("key") || p.accept("index")) && p.peek().isName() && p.pos+1 < len(p.toks) && p.toks[p.pos+1].is("(") {
+			if name == "" {
+				name = p.peek().text
+			}
+			p.pos++
+		}
+		p.skipTo("(")
+		names, err := p.columnList("unique key of table '" + t.Name + "'")
+		if err != nil {
+			return err
+		}
+		if err := p.knownColumns(t, names, "unique key"); err != nil {
+			return err
+		}
+		t.Unique = append(t.Unique, UniqueKey{name, names})
+		return nil
+
+	case p.accept("check"):
+		expr, err := p.checkExpr(t.Name)
+		if err != nil {
+			return err
+		}
+		t.Checks = append(t.Checks, Check{name, expr})
+		return nil
+
+	case p.accept("foreign", "key"):
+		// MySQL allows index name before columns
+		p.skipTo("(")
+		names, err := p.columnList("foreign key of table '" + t.Name + "'")
+		if err != nil {
+			return err
+		}
+		if err := p.knownColumns(t, names, "foreign key"); err != nil {
+			return err
+		}
+		if !p.accept("references") {
+			return p.errorf("expected REFERENCES of foreign key of table '%s'", t.Name)
+		}
+		fk, err := p.references(names)
+		if err != nil {
+			return err
+		}
+		fk.Name = name
+		t.ForeignKeys = append(t.ForeignKeys, fk)
+		return nil
+
+	case p.accept("default"):
+		start := p.pos
+		for !p.eof() && !p.peek().is("for") {
+			p.skip()
+		}
+		def := p.toks[start:p.pos]
+		if len(def) == 0 {
+			return p.errorf("expected default value of table '%s'", t.Name)
+		}
+		if !p.accept("for") {
+			return p.errorf("expected FOR of default of table '%s'", t.Name)
+		}
+		col, err := p.name()
+		if err != nil {
+			return err
+		}
+		c := t.Column(col)
+		if c == nil {
+			return p.errorf("unknown column '%s' of table '%s'", col, t.Name)
+		}
+		c.Default = renderTokens(def)
+		return nil
+	}
+	return nil
+}
+
+// constraintKinds are words starting constraint after its name
+var constraintKinds = map[string]bool{
+	"primary": true, "unique": true, "check": true, "foreign": true, "default": true, "references": true,
+	"not": true, "null": true,
+}
+
+// constraintName consumes 'CONSTRAINT name' and returns the name, or empty string if constraint is not named
+func (p *ddlParser) constraintName() (string, error) {
+	if !p.accept("constraint") {
+		return "", nil
+	}
+	name := ""
+	// name is optional in MySQL
+	if t := p.peek(); t.kind == tokIdent || t.kind == tokWord && !constraintKinds[strings.ToLower(t.text)] {
+		name = p.next().text
+	}
+	if p.eof() {
+		return "", p.errorf("expected constraint after CONSTRAINT")
+	}
+	return name, nil
+}
+
+// skipTo consumes tokens up to given punctuation or keyword
+func (p *ddlParser) skipTo(word string) {
+	for !p.eof() && !p.peek().is(word) {
+		p.pos++
+	}
+}
+
+// columnList consumes list of columns in parentheses like '(a, b desc)' and returns names of columns
+func (p *ddlParser) columnList(what string) ([]string, error) {
+	if !p.peek().is("(") {
+		return nil, p.errorf("expected columns of %s", what)
+	}
+	cols, err := p.group()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, col := range splitTokens(cols) {
+		if len(col) == 0 || !col[0].isName() {
+			return nil, p.errorf("invalid column in %s", what)
+		}
+		names = append(names, col[0].text)
+	}
+	if len(names) == 0 {
+		return nil, p.errorf("expected columns of %s", what)
+	}
+	return names, nil
+}
+
+// knownColumns checks that t has all given columns of a constraint
+func (p *ddlParser) knownColumns(t *Table, names []string, what string) error {
+	for _, name := range names {
+		if t.Column(name) == nil {
+			return p.errorf("unknown column '%s' in %s of table '%s'", name, what, t.Name)
+		}
+	}
+	return nil
+}
+
+// checkExpr consumes expression of CHECK constraint in parentheses
+func (p *ddlParser) checkExpr(table string) (string, error) {
+	if !p.peek().is("(") {
+		return "", p.errorf("expected expression of check of table '%s'", table)
+	}
+	expr, err := p.group()
+	if err != nil {
+		return "", err
+	}
+	if len(expr) == 0 {
+		return "", p.errorf("expected expression of check of table '%s'", table)
+	}
+	return renderTokens(expr), nil
+}
+
+// references consumes referenced table, columns and actions of foreign key on given columns, after REFERENCES
+func (p *ddlParser) references(cols []string) (ForeignKey, error) {
+	ref, err := p.name()
+	if err != nil {
+		return ForeignKey{}, err
+	}
+	fk := ForeignKey{Columns: cols, RefTable: ref}
+	if p.peek().is("(") {
+		if fk.RefColumns, err = p.columnList("references to '" + ref + "'"); err != nil {
+			return ForeignKey{}, err
+		}
+		if len(fk.RefColumns) != len(cols) {
+			return ForeignKey{}, p.errorf("foreign key of %d columns references %d columns of '%s'", len(cols), len(fk.RefColumns), ref)
+		}
+	}
+	for {
+		switch {
+		case p.accept("on", "delete"):
+			fk.OnDelete, err = p.refAction()
+		case p.accept("on", "update"):
+			fk.OnUpdate, err = p.refAction()
+		case p.accept("match"):
+			// MATCH FULL, PARTIAL or SIMPLE
+			p.pos++
+		default:
+			return fk, nil
+		}
+		if err != nil {
+			return ForeignKey{}, err
+		}
+	}
+}
+
+// refActions are actions of foreign keys
+var refActions = [][]string{{"cascade"}, {"restrict"}, {"no", "action"}, {"set", "null"}, {"set", "default"}}
+
+// refAction consumes action of ON DELETE or ON UPDATE of foreign key
+func (p *ddlParser) refAction() (string, error) {
+	for _, action := range refActions {
+		if p.accept(action...) {
+			// PostgreSQL allows columns of SET NULL and SET DEFAULT
+			if p.peek().is("(") {
+				p.skip()
+			}
+			return strings.Join(action, " "), nil
+		}
+	}
+	return "", p.errorf("unknown action '%s' of foreign key", p.peek().text)
+}
+
+// setPrimaryKey sets primary key of t to given columns
+func (p *ddlParser) setPrimaryKey(t *Table, names []string) error {
+	for i := range t.Columns {
+		t.Columns[i].PrimaryKey = false
+	}
+	t.PrimaryKey = nil
+	for _, name := range names {
+		c := t.Column(name)
+		if c == nil {
+			return p.errorf("unknown column '%s' in primary key of table '%s'", name, t.Name)
+		}
+		c.PrimaryKey = true
+		c.NotNull = true
+		t.PrimaryKey = append(t.PrimaryKey, c.Name)
+	}
+	return nil
+}
+
+// columnAttrs are words ending type of column definition
+var columnAttrs = map[string]bool{
+	"not": true, "null": true, "default": true, "primary": true, "unique": true, "references": true, "check": true,
+	"constraint": true, "identity": true, "auto_increment": true, "autoincrement": true, "generated": true,
+	"collate": true, "comment": true, "charset": true, "on": true, "as": true, "rowguidcol": true, "sparse": true,
+	"filestream": true, "masked": true, "stored": true, "virtual": true, "persisted": true, "invisible": true,
+	"visible": true, "key": true, "using": true,
+}
+
+// isColumnAttr tells whether current token ends type of column
+func (p *ddlParser) isColumnAttr() bool {
+	t := p.peek()
+	if t.kind != tokWord {
+		return false
+	}
+	if t.is("character") {
+		return p.pos+1 < len(p.toks) && p.toks[p.pos+1].is("set")
+	}
+	return columnAttrs[strings.ToLower(t.text)]
+}
+
+// columnType consumes type of column
+func (p *ddlParser) columnType() string {
+	start := p.pos
+	for !p.eof() && !p.isColumnAttr() {
+		p.skip()
+	}
+	return renderTokens(p.toks[start:p.pos])
+}
+
+// column parses column definition of table t, constraints of column are added to t
+func (p *ddlParser) column(t *Table) (Column, error) {
+	if !p.peek().isName() {
+		return Column{}, p.errorf("expected column name, got '%s'", p.peek().text)
+	}
+	c := Column{Name: p.next().text}
+	c.Type = p.columnType()
+	return c, p.columnAttrs(t, &c)
+}
+
+// columnAttrs applies constraints and attributes following type of column, unique, check and foreign key
+// constraints are added to t
+func (p *ddlParser) columnAttrs(t *Table, c *Column) error {
+	for !p.eof() {
+		name, err := p.constraintName()
+		if err != nil {
+			return err
+		}
+		switch {
+		case p.accept("not", "null"):
+			c.NotNull = true
+		case p.accept("null"):
+			c.NotNull = false
+		case p.accept("not", "for", "replication"):
+		case p.accept("default"):
+			if p.eof() || p.isColumnAttr() && !p.peek().is("null") {
+				return p.errorf("expected default value of column '%s'", c.Name)
+			}
+			start := p.pos
+			p.skip()
+			for !p.eof() && !p.isColumnAttr() {
+				p.skip()
+			}
+			c.Default = renderTokens(p.toks[start:p.pos])
+			if strings.EqualFold(c.Default, "null") {
+				c.Default = ""
+			}
+		case p.accept("primary", "key"):
+			c.PrimaryKey = true
+			c.NotNull = true
+		case p.accept("unique"):
+			p.accept("key")
+			t.Unique = append(t.Unique, UniqueKey{name, []string{c.Name}})
+		case p.accept("check"):
+			expr, err := p.checkExpr(t.Name)
+			if err != nil {
+				return err
+			}
+			t.Checks = append(t.Checks, Check{name, expr})
+		case p.accept("references"):
+			fk, err := p.references([]string{c.Name})
+			if err != nil {
+				return err
+			}
+			fk.Name = name
+			t.ForeignKeys = append(t.ForeignKeys, fk)
+		case p.accept("identity"), p.accept("auto_increment"), p.accept("autoincrement"):
+			c.Identity = true
+			if p.peek().is("(") {
+				p.skip()
+			}
+		case p.accept("generated"):
+			// GENERATED {ALWAYS | BY DEFAULT} AS {IDENTITY | (expr) [STORED | VIRTUAL]}
+			for !p.eof() && !p.peek().is("as") {
+				p.pos++
+			}
+			p.accept("as")
+			if p.accept("identity") {
+				c.Identity = true
+			} else {
+				c.Computed = true
+			}
+			if p.peek().is("(") {
+				p.skip()
+			}
+		case p.accept("as"):
+			// computed column of MS-SQL and MySQL
+			c.Computed = true
+			if p.peek().is("(") {
+				p.skip()
+			}
+		case p.accept("on"):
+			// ON UPDATE CURRENT_TIMESTAMP of MySQL, actions of foreign key are consumed by references
+			p.pos++
+			p.pos++
+			if p.peek().is("(") {
+				p.skip()
+			}
+		case p.accept("character", "set"), p.accept("charset"), p.accept("collate"), p.accept("comment"):
+			p.skip()
+		default:
+			p.skip()
+		}
+	}
+	return nil
+}
+
+// alterActions starts actions of ALTER TABLE, items not starting with these continue previous ADD or DROP COLUMN
+var alterActions = map[string]bool{
+	"add": true, "drop": true, "alter": true, "modify": true, "change": true, "rename": true,
+}
+
+func (p *ddlParser) alterTable() error {
+	ifExists := p.accept("if", "exists")
+	p.accept("only")
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	t := p.schema.Lookup(name)
+	if t == nil {
+		if ifExists {
+			return nil
+		}
+		return p.errorf("alter of unknown table '%s'", name)
+	}
+	// WITH CHECK ADD CONSTRAINT of MS-SQL
+	if !p.accept("with", "check") {
+		p.accept("with", "nocheck")
+	}
+
+	last := ""
+	for _, action := range splitTokens(p.toks[p.pos:]) {
+		if len(action) == 0 {
+			continue
+		}
+		sub := ddlParser{schema: p.schema, toks: action}
+		verb := strings.ToLower(action[0].text)
+		if action[0].kind == tokWord && alterActions[verb] {
+			sub.pos++
+			last = verb
+			if verb == "drop" && sub.peek().is("column") {
+				last = "drop column"
+			}
+		} else if last == "add" || last == "drop column" {
+			verb = last
+		} else {
+			continue
+		}
+
+		switch verb {
+		case "add":
+			err = sub.alterAdd(t)
+		case "drop", "drop column":
+			err = sub.alterDrop(t)
+		case "alter":
+			err = sub.alterColumn(t)
+		case "modify", "change":
+			err = sub.alterModify(t, verb == "change")
+		case "rename":
+			err = sub.alterRename(t)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ddlParser) alterAdd(t *Table) error {
+	if p.accept("column") {
+		p.accept("if", "not", "exists")
+	}
+	if p.peek().kind == tokWord && constraintStarts[strings.ToLower(p.peek().text)] {
+		return p.tableConstraint(t)
+	}
+	c, err := p.column(t)
+	if err != nil {
+		return err
+	}
+	t.AddColumn(c)
+	if c.PrimaryKey {
+		return p.setPrimaryKey(t, []string{c.Name})
+	}
+	return nil
+}
+
+func (p *ddlParser) alterDrop(t *Table) error {
+	switch {
+	case p.accept("primary", "key"):
+		return p.setPrimaryKey(t, nil)
+	case p.accept("constraint"), p.accept("foreign", "key"), p.accept("check"), p.accept("index"), p.accept("key"):
+		p.accept("if", "exists")
+		if !p.peek().isName() {
+			return p.errorf("expected name of constraint of table '%s'", t.Name)
+		}
+		// names of primary keys, defaults and indexes are not kept by Schema
+		t.DropConstraint(p.next().text)
+		return nil
+	case p.peek().kind == tokWord && constraintStarts[strings.ToLower(p.peek().text)]:
+		return nil
+	}
+	p.accept("column")
+	ifExists := p.accept("if", "exists")
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	if t.Column(name) == nil {
+		if ifExists {
+			return nil
+		}
+		return p.errorf("drop of unknown column '%s' of table '%s'", name, t.Name)
+	}
+	t.DropColumn(name)
+	if containsFold(t.PrimaryKey, name) {
+		var pk []string
+		for _, col := range t.PrimaryKey {
+			if !strings.EqualFold(col, name) {
+				pk = append(pk, col)
+			}
+		}
+		t.PrimaryKey = pk
+	}
+	return nil
+}
+
+// alterColumn applies ALTER COLUMN of PostgreSQL, MySQL and MS-SQL
+func (p *ddlParser) alterColumn(t *Table) error {
+	p.accept("column")
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	c := t.Column(name)
+	if c == nil {
+		return p.errorf("alter of unknown column '%s' of table '%s'", name, t.Name)
+	}
+	if p.eof() {
+		return p.errorf("expected change of column '%s' of table '%s'", name, t.Name)
+	}
+
+	switch {
+	case p.accept("type"), p.accept("set", "data", "type"):
+		typ := p.columnType()
+		if typ == "" {
+			return p.errorf("expected type of column '%s' of table '%s'", name, t.Name)
+		}
+		c.Type = typ
+	case p.accept("set", "not", "null"):
+		c.NotNull = true
+	case p.accept("drop", "not", "null"):
+		c.NotNull = false
+	case p.accept("set", "default"):
+		if p.eof() {
+			return p.errorf("expected default value of column '%s'", name)
+		}
+		c.Default = renderTokens(p.toks[p.pos:])
+	case p.accept("drop", "default"):
+		c.Default = ""
+	case p.accept("add", "generated"):
+		c.Identity = true
+	case p.accept("drop", "identity"):
+		c.Identity = false
+	case p.peek().is("set") || p.peek().is("drop") || p.peek().is("add") || p.peek().is("reset"):
+	default:
+		// MS-SQL redefines type and nullability
+		typ := p.columnType()
+		if typ == "" {
+			return p.errorf("expected type of column '%s' of table '%s'", name, t.Name)
+		}
+		c.Type = typ
+		c.NotNull = false
+		return p.columnAttrs(t, c)
+	}
+	return nil
+}
+
+// alterModify applies MODIFY and CHANGE of MySQL, which redefine column
+func (p *ddlParser) alterModify(t *Table, rename bool) error {
+	p.accept("column")
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	old := t.Column(name)
+	if old == nil {
+		return p.errorf("alter of unknown column '%s' of table '%s'", name, t.Name)
+	}
+	if !rename {
+		p.pos--
+	}
+	c, err := p.column(t)
+	if err != nil {
+		return err
+	}
+	c.PrimaryKey = c.PrimaryKey || old.PrimaryKey
+	c.NotNull = c.NotNull || c.PrimaryKey
+	*old = c
+	p.renameKeys(t, name, c.Name)
+	return nil
+}
+
+func (p *ddlParser) alterRename(t *Table) error {
+	switch {
+	case p.accept("to"), p.accept("as"):
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		p.renameTable(t, name)
+		return nil
+	case p.accept("constraint"), p.accept("index"), p.accept("key"):
+		return nil
+	}
+	column := p.accept("column")
+	old, err := p.name()
+	if err != nil {
+		return err
+	}
+	if !p.accept("to") {
+		if column {
+			return p.errorf("expected TO")
+		}
+		// 'RENAME name' of MySQL renames table
+		p.renameTable(t, old)
+		return nil
+	}
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	return p.renameColumn(t, old, name)
+}
+
+func (p *ddlParser) renameColumn(t *Table, old, name string) error {
+	c := t.Column(old)
+	if c == nil {
+		return p.errorf("rename of unknown column '%s' of table '%s'", old, t.Name)
+	}
+	c.Name = name
+	p.renameKeys(t, old, name)
+	return nil
+}
+
+// renameKeys renames column of t in its keys and in foreign keys referencing t
+func (p *ddlParser) renameKeys(t *Table, old, name string) {
+	renameIn(t.PrimaryKey, old, name)
+	for _, u := range t.Unique {
+		renameIn(u.Columns, old, name)
+	}
+	for _, fk := range t.ForeignKeys {
+		renameIn(fk.Columns, old, name)
+	}
+	for _, other := range p.schema.Tables() {
+		for _, fk := range other.ForeignKeys {
+			if strings.EqualFold(fk.RefTable, t.Name) {
+				renameIn(fk.RefColumns, old, name)
+			}
+		}
+	}
+}
+
+func renameIn(cols []string, old, name string) {
+	for i, col := range cols {
+		if strings.EqualFold(col, old) {
+			cols[i] = name
+		}
+	}
+}
+
+func (p *ddlParser) renameTable(t *Table, name string) {
+	for _, other := range p.schema.Tables() {
+		for i := range other.ForeignKeys {
+			if strings.EqualFold(other.ForeignKeys[i].RefTable, t.Name) {
+				other.ForeignKeys[i].RefTable = name
+			}
+		}
+	}
+	p.schema.Drop(t.Name)
+	t.Name = name
+	p.schema.Add(t)
+}
+
+// renameTables applies 'RENAME TABLE a TO b, c TO d' of MySQL
+func (p *ddlParser) renameTables() error {
+	for _, item := range splitTokens(p.toks[p.pos:]) {
+		sub := ddlParser{schema: p.schema, toks: item}
+		old, err := sub.name()
+		if err != nil {
+			return err
+		}
+		if !sub.accept("to") {
+			return sub.errorf("expected TO")
+		}
+		name, err := sub.name()
+		if err != nil {
+			return err
+		}
+		t := p.schema.Lookup(old)
+		if t == nil {
+			return sub.errorf("rename of unknown table '%s'", old)
+		}
+		p.renameTable(t, name)
+	}
+	return nil
+}
+
+// spRename applies 'EXEC sp_rename 'old', 'new' [, 'COLUMN']' of MS-SQL
+func (p *ddlParser) spRename() error {
+	if name, err := p.name(); err != nil || !strings.EqualFold(name, "sp_rename") {
+		return nil
+	}
+	var args []string
+	for _, arg := range splitTokens(p.toks[p.pos:]) {
+		// arguments may be named like @objname = 'users'
+		last := arg[len(arg)-1]
+		text := last.text
+		if last.kind == tokString {
+			text = text[strings.IndexByte(text, '\'')+1 : len(text)-1]
+		}
+		args = append(args, strings.NewReplacer("[", "", "]", "").Replace(text))
+	}
+	if len(args) < 2 {
+		return p.errorf("sp_rename requires old and new name")
+	}
+
+	old, name := args[0], args[1]
+	if len(args) > 2 && strings.EqualFold(args[2], "column") {
+		i := strings.LastIndex(old, ".")
+		if i < 0 {
+			return p.errorf("column '%s' must be qualified by table", old)
+		}
+		t := p.schema.Lookup(old[:i])
+		if t == nil {
+			return p.errorf("rename of column of unknown table '%s'", old[:i])
+		}
+		return p.renameColumn(t, old[i+1:], name)
+	}
+	if len(args) > 2 && !strings.EqualFold(args[2], "object") {
+		// indexes and other objects
+		return nil
+	}
+	t := p.schema.Lookup(old)
+	if t == nil {
+		return p.errorf("rename of unknown table '%s'", old)
+	}
+	p.renameTable(t, name)
+	return nil
+}
+
+// drop applies DROP TABLE and DROP VIEW, dropping unknown tables is ignored
+func (p *ddlParser) drop() error {
+	p.accept("materialized")
+	if !p.accept("table") && !p.accept("view") {
+		return nil
+	}
+	p.accept("if", "exists")
+	for _, item := range splitTokens(p.toks[p.pos:]) {
+		sub := ddlParser{schema: p.schema, toks: item}
+		name, err := sub.name()
+		if err != nil {
+			return err
+		}
+		if t := p.schema.Lookup(name); t != nil {
+			p.schema.Drop(t.Name)
+		}
+	}
+	return nil
+}
+
+// view applies CREATE VIEW and ALTER VIEW, columns are derived from select-list unless listed
+func (p *ddlParser) view(create bool) error {
+	if create {
+		p.accept("if", "not", "exists")
+	}
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	var names []ddlToken
+	if p.peek().is("(") {
+		if names, err = p.group(); err != nil {
+			return err
+		}
+	}
+	for !p.eof() && !p.peek().is("as") {
+		// WITH options of views
+		p.skip()
+	}
+	if !p.accept("as") {
+		if !create {
+			// other changes of view like RENAME or OWNER
+			return nil
+		}
+		return p.errorf("expected AS of view '%s'", name)
+	}
+
+	cols, err := p.selectColumns(p.toks[p.pos:])
+	if err != nil {
+		return err
+	}
+	t := &Table{Name: name, View: true, Columns: cols}
+	for i, col := range splitTokens(names) {
+		if len(col) == 0 {
+			continue
+		}
+		if i < len(t.Columns) {
+			t.Columns[i].Name = col[0].text
+		} else {
+			t.Columns = append(t.Columns, Column{Name: col[0].text})
+		}
+	}
+	p.schema.Add(t)
+	return nil
+}
+
+// selectEnds are words ending FROM clause of select
+var selectEnds = map[string]bool{
+	"where": true, "group": true, "having": true, "order": true, "limit": true, "union": true, "intersect": true,
+	"except": true, "window": true, "offset": true, "fetch": true, "for": true, "with": true,
+}
+
+// joinWords are words which cannot be alias of a source in FROM clause
+var joinWords = map[string]bool{
+	"on": true, "join": true, "left": true, "right": true, "inner": true, "outer": true, "cross": true, "full": true,
+	"natural": true, "using": true, "lateral": true, "straight_join": true, "apply": true,
+}
+
+// selectColumns returns columns of select-list of given select, types are known for columns of known tables
+func (p *ddlParser) selectColumns(toks []ddlToken) ([]Column, error) {
+	// find select of main query after CTEs
+	start, depth := -1, 0
+	for i, t := range toks {
+		if t.is("(") {
+			depth++
+		} else if t.is(")") {
+			depth--
+		} else if depth == 0 && t.is("select") {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil, p.errorf("expected SELECT")
+	}
+	sel := ddlParser{schema: p.schema, toks: toks[start:]}
+	sel.accept("distinct")
+	sel.accept("all")
+	if sel.accept("top") {
+		sel.skip()
+		sel.accept("percent")
+	}
+
+	listStart := sel.pos
+	for !sel.eof() && !sel.peek().is("from") && !(sel.peek().kind == tokWord && selectEnds[strings.ToLower(sel.peek().text)]) {
+		sel.skip()
+	}
+	list := sel.toks[listStart:sel.pos]
+	if len(list) == 0 {
+		return nil, p.errorf("expected columns of SELECT")
+	}
+
+	// sources of FROM clause by alias and name in order, nil for derived tables
+	var aliases []string
+	sources := make(map[string]*Table)
+	if sel.accept("from") {
+		expectSource := true
+		for !sel.eof() && !(sel.peek().kind == tokWord && selectEnds[strings.ToLower(sel.peek().text)]) {
+			t := sel.peek()
+			switch {
+			case t.is(",") || t.is("join") || t.is("apply"):
+				expectSource = true
+				sel.pos++
+			case expectSource && t.is("lateral"):
+				sel.pos++
+			case expectSource:
+				expectSource = false
+				var src *Table
+				name := ""
+				if t.is("(") {
+					sel.skip()
+				} else if n, err := sel.name(); err == nil {
+					name = n
+					src = p.schema.Lookup(n)
+				} else {
+					sel.pos++
+					continue
+				}
+				sel.accept("as")
+				alias := name
+				if sel.peek().isName() && !joinWords[strings.ToLower(sel.peek().text)] && !selectEnds[strings.ToLower(sel.peek().text)] {
+					alias = sel.next().text
+				}
+				if alias != "" {
+					aliases = append(aliases, strings.ToLower(alias))
+					sources[strings.ToLower(alias)] = src
+				}
+			default:
+				sel.skip()
+			}
+		}
+	}
+
+	var cols []Column
+	for _, item := range splitTokens(list) {
+		cols = append(cols, itemColumns(item, aliases, sources)...)
+	}
+	return cols, nil
+}
+
+// itemColumns returns columns of an item of select-list
+func itemColumns(item []ddlToken, aliases []string, sources map[string]*Table) []Column {
+	n := len(item)
+	switch {
+	case n == 0:
+		return nil
+	case n == 1 && item[0].is("*"):
+		var cols []Column
+		for _, alias := range aliases {
+			if src := sources[alias]; src != nil {
+				cols = append(cols, viewColumns(src)...)
+			}
+		}
+		return cols
+	case n == 3 && item[1].is(".") && item[2].is("*"):
+		if src := sources[strings.ToLower(item[0].text)]; src != nil {
+			return viewColumns(src)
+		}
+		return nil
+	}
+
+	// alias as 'expr AS name', 'expr name' or 'name = expr' of MS-SQL
+	name := ""
+	expr := item
+	switch {
+	case n > 2 && item[n-2].is("as") && item[n-1].isName():
+		name, expr = item[n-1].text, item[:n-2]
+	case n > 2 && item[0].isName() && item[1].is("="):
+		name, expr = item[0].text, item[2:]
+	case n > 1 && item[n-1].isName() && !item[n-1].is("end") &&
+		(item[n-2].isName() || item[n-2].is(")") || item[n-2].kind == tokString || item[n-2].kind == tokNumber):
+		name, expr = item[n-1].text, item[:n-1]
+	}
+
+	col := Column{Name: name}
+	switch m := len(expr); {
+	case m == 1 && expr[0].isName():
+		for _, alias := range aliases {
+			if src := sources[alias]; src != nil && src.Column(expr[0].text) != nil {
+				col = viewColumn(*src.Column(expr[0].text), name)
+				break
+			}
+		}
+		if col.Name == "" {
+			col.Name = expr[0].text
+		}
+	case m == 3 && expr[0].isName() && expr[1].is(".") && expr[2].isName():
+		if src := sources[strings.ToLower(expr[0].text)]; src != nil && src.Column(expr[2].text) != nil {
+			col = viewColumn(*src.Column(expr[2].text), name)
+		}
+		if col.Name == "" {
+			col.Name = expr[2].text
+		}
+	case m > 2 && expr[0].is("cast") && expr[1].is("(") && expr[m-1].is(")"):
+		depth := 0
+		for i := 2; i < m-1; i++ {
+			if expr[i].is("(") {
+				depth++
+			} else if expr[i].is(")") {
+				depth--
+			} else if depth == 0 && expr[i].is("as") {
+				col.Type = renderTokens(expr[i+1 : m-1])
+			}
+		}
+	default:
+		for i := m - 1; i > 0; i-- {
+			if expr[i].is("::") {
+				col.Type = renderTokens(expr[i+1:])
+				break
+			}
+		}
+	}
+	if col.Name == "" {
+		return nil
+	}
+	return []Column{col}
+}
+
+// viewColumns returns columns of source as columns of view
+func viewColumns(src *Table) []Column {
+	cols := make([]Column, len(src.Columns))
+	for i, c := range src.Columns {
+		cols[i] = viewColumn(c, "")
+	}
+	return cols
+}
+
+// viewColumn returns column of source as column of view with given name, or same name if empty
+func viewColumn(c Column, name string) Column {
+	if name == "" {
+		name = c.Name
+	}
+	return Column{Name: name, Type: c.Type, NotNull: c.NotNull}
+}
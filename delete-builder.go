@@ -64,9 +64,9 @@ func (u *deleteBuilder) Returning(cols ...string) *deleteBuilder {
 
 // Build generates the insert sql statement
 func (u *deleteBuilder) Build(terminateWithSemiColon bool) StatementInfo {
+	u.validateSchema(u)
 	var sql strings.Builder
-	u.paramCounter = 0
-	u.fieldCounter = 0
+	u.reset(0)
 
 	sql.WriteString("delete from ")
 	sql.WriteString(u.table)
@@ -1,3 +1,5 @@
 module github.com/samtech09/gosql
 
-go 1.13
+go 1.16
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -37,7 +37,9 @@ func (n *insertBuilder) Returning(cols ...string) *insertBuilder {
 
 //Build generates the insert sql statement along with meta information.
 func (n *insertBuilder) Build(terminateWithSemiColon bool) StatementInfo {
+	n.validateSchema(n)
 	var sql strings.Builder
+	n.reset(0)
 
 	// get count of fields
 	cnt := len(n.fields)
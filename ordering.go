@@ -0,0 +1,120 @@
+package gosql
+
+import (
+	"strconv"
+	"strings"
+)
+
+type nullsOrder int
+
+const (
+	nullsDefault nullsOrder = iota
+	nullsFirst
+	nullsLast
+)
+
+// Ordering holds a term of ORDER BY clause.
+type Ordering struct {
+	expr       string
+	position   int
+	descending bool
+	nulls      nullsOrder
+	collation  string
+	caseExpr   *CaseExpr
+}
+
+// OrderExpr creates ascending Ordering on given field or expression.
+func OrderExpr(expr string) *Ordering {
+	return &Ordering{expr: strings.Trim(expr, " ")}
+}
+
+// OrderPos creates ascending Ordering on given position (starting at 1) of select-list.
+func OrderPos(position int) *Ordering {
+	if position < 1 {
+		panic("order position must start from 1")
+	}
+	return &Ordering{position: position}
+}
+
+// OrderCase creates ascending Ordering on given CASE expression.
+func OrderCase(c *CaseExpr) *Ordering {
+	return &Ordering{caseExpr: c}
+}
+
+// Desc sets descending order.
+func (o *Ordering) Desc() *Ordering {
+	o.descending = true
+	return o
+}
+
+// NullsFirst sorts NULL values before non-null values.
+// On MySQL and MS-SQL it is emulated with CASE expression, when it differs from default ordering of NULLs.
+func (o *Ordering) NullsFirst() *Ordering {
+	o.nulls = nullsFirst
+	return o
+}
+
+// NullsLast sorts NULL values after non-null values.
+// On MySQL and MS-SQL it is emulated with CASE expression, when it differs from default ordering of NULLs.
+func (o *Ordering) NullsLast() *Ordering {
+	o.nulls = nullsLast
+	return o
+}
+
+// Collate sets collation used for ordering. Collation name is written as given, so quote it if database requires.
+func (o *Ordering) Collate(collation string) *Ordering {
+	o.collation = strings.Trim(collation, " ")
+	return o
+}
+
+// writeOrdering writes given ORDER BY term as per database type
+func (b *builder) writeOrdering(sql *strings.Builder, o *Ordering) {
+	expr := o.expr
+	if o.position > 0 {
+		expr = strconv.Itoa(o.position)
+	}
+
+	// MySQL and MS-SQL sort NULLs first in ascending and last in descending order,
+	// emulate NULLS FIRST/LAST only when required ordering is other than default
+	if b.dbtype != DbTypePostgreSQL && o.nulls != nullsDefault && o.descending == (o.nulls == nullsFirst) {
+		if o.position > 0 {
+			panic("nulls first/last on select-list position is not applicable to mssql/mysql")
+		}
+		sql.WriteString("case when ")
+		b.writeOrderingExpr(sql, o, expr)
+		if o.nulls == nullsFirst {
+			sql.WriteString(" is null then 0 else 1 end, ")
+		} else {
+			sql.WriteString(" is null then 1 else 0 end, ")
+		}
+	}
+
+	b.writeOrderingExpr(sql, o, expr)
+	if o.collation != "" {
+		sql.WriteString(" collate ")
+		sql.WriteString(o.collation)
+	}
+	if o.descending {
+		sql.WriteString(" desc")
+	} else {
+		sql.WriteString(" asc")
+	}
+
+	if b.dbtype == DbTypePostgreSQL {
+		switch o.nulls {
+		case nullsFirst:
+			sql.WriteString(" nulls first")
+		case nullsLast:
+			sql.WriteString(" nulls last")
+		}
+	}
+}
+
+// writeOrderingExpr writes expression of ORDER BY term
+func (b *builder) writeOrderingExpr(sql *strings.Builder, o *Ordering, expr string) {
+	if o.caseExpr != nil {
+		b.writeCase(sql, o.caseExpr, "")
+		return
+	}
+	b.writeParamSQL(sql, expr, exprName(expr))
+}
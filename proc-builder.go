@@ -1,7 +1,6 @@
 package gosql
 
 import (
-	"os"
 	"strconv"
 	"strings"
 )
@@ -10,6 +9,7 @@ import (
 //It allows to generate SELECT sql statements.
 func ProcBuilder() *procBuilder {
 	s := procBuilder{}
+	s.conditionGroups = make(map[int]conditionGroup)
 	s.limitRows = 0
 	s.readonly = true
 	s.initEnv()
@@ -24,13 +24,31 @@ func (s *procBuilder) Select(fields ...string) *procBuilder {
 	return s
 }
 
-//Select specifies the fields for select clause.
+//Perform specifies name of function to be executed discarding its result.
+//On PostgreSQL it renders as 'select fn(...)', as PERFORM is valid inside PL/pgSQL only.
+//Statement is not ReadOnly by default.
 func (s *procBuilder) Perform(procname string) *procBuilder {
 	if procname == "" {
 		panic("invalid procname")
 	}
 	s.proc = procname
 	s.perform = true
+	s.call = false
+	s.readonly = false
+	return s
+}
+
+//Call specifies name of stored procedure to be invoked with CALL, as required by PostgreSQL 11+ procedures.
+//OUT parameters are passed as null and values of OUT and INOUT parameters are returned as a single row.
+//On MS-SQL and MySQL it is same as Perform. Statement is not ReadOnly by default.
+func (s *procBuilder) Call(procname string) *procBuilder {
+	if procname == "" {
+		panic("invalid procname")
+	}
+	s.proc = procname
+	s.perform = true
+	s.call = true
+	s.readonly = false
 	return s
 }
 
@@ -42,17 +60,103 @@ func (s *procBuilder) FromProc(procname string) *procBuilder {
 	}
 	s.proc = procname
 	s.perform = false
+	s.call = false
 	return s
 }
 
-//Select specifies the fields for select clause.
+//Param adds input parameters of proc in order of declaration.
 func (s *procBuilder) Param(paraNames ...string) *procBuilder {
+	return s.addArgs(argIn, paraNames)
+}
+
+//OutParam adds OUTPUT parameters of proc in order of declaration.
+//
+//On MS-SQL it renders as '@p2 output' and value is received through bound parameter.
+//On MySQL it renders as session variable '@name' to be selected after call.
+//On PostgreSQL OUT parameters are not passed to function, these are part of result columns instead.
+func (s *procBuilder) OutParam(paraNames ...string) *procBuilder {
+	return s.addArgs(argOut, paraNames)
+}
+
+//InOutParam adds INOUT parameters of proc in order of declaration.
+//
+//On MS-SQL it renders as '@p2 output' and bound parameter carries value in both directions.
+//On MySQL it renders as session variable '@name' that must be set before call.
+//On PostgreSQL it is passed as input parameter.
+func (s *procBuilder) InOutParam(paraNames ...string) *procBuilder {
+	return s.addArgs(argInOut, paraNames)
+}
+
+//ArgExpr adds arguments written as given expressions, like column of preceding table
+//when function is joined in SelectBuilder. '?' in expression is replaced by parameter.
+func (s *procBuilder) ArgExpr(exprs ...string) *procBuilder {
+	return s.addArgs(argExpr, exprs)
+}
+
+//NamedArgs passes parameters by name i.e. '@email=@p1' on MS-SQL and 'email => $1' on PostgreSQL.
+//It is not supported by MySQL.
+func (s *procBuilder) NamedArgs() *procBuilder {
+	s.namedArgs = true
+	return s
+}
+
+func (s *procBuilder) addArgs(direction argDirection, paraNames []string) *procBuilder {
 	for _, v := range paraNames {
-		s.args = append(s.args, strings.Trim(v, " "))
+		s.args = append(s.args, procArg{strings.Trim(v, " "), direction})
 	}
 	return s
 }
 
+//As sets alias of function result, along with column definitions required by functions returning record.
+//For example
+//
+//	As("u", "id integer", "name text")
+//
+//renders as 'from getusers($1) as u(id integer, name text)'.
+func (s *procBuilder) As(alias string, columnDefs ...string) *procBuilder {
+	s.alias = strings.Trim(alias, " ")
+	s.columnDefs = trimAll(columnDefs)
+	return s
+}
+
+//Where specifies the WHERE clause of sql to filter result of function. It accepts one or more Conditions.
+//It is supported by PostgreSQL only.
+func (s *procBuilder) Where(c ...ICondition) *procBuilder {
+	cg := conditionGroup{}
+	cg.outer_op = opdefault
+	cg.conditions = make([]Condition, 0, len(c))
+	for _, cd := range c {
+		cg.conditions = append(cg.conditions, cd.(Condition))
+	}
+
+	l := len(s.conditionGroups)
+	s.conditionGroups[l] = cg
+	return s
+}
+
+//WhereGroup adds another grouped condition with AND or OR where clause after the default where clause.
+//
+//outerOp defined operator between two WhereGroups or between a WhereGroup and main where block.
+//
+//innerOp defines operator between two conditions within the WhereGroup
+func (s *procBuilder) WhereGroup(outerOp Operator, innerOp Operator, c ...ICondition) *procBuilder {
+	l := len(s.conditionGroups)
+	if l < 1 {
+		panic("default Where condition must be added first")
+	}
+
+	cg := conditionGroup{}
+	cg.outer_op = outerOp
+	cg.inner_op = innerOp
+	cg.conditions = make([]Condition, 0, len(c))
+	for _, cd := range c {
+		cg.conditions = append(cg.conditions, cd.(Condition))
+	}
+
+	s.conditionGroups[l] = cg
+	return s
+}
+
 //OrderBy specifies the ORDER BY clause of sql. Different fields may have different ordering (asc or desc).
 func (s *procBuilder) OrderBy(fieldname string, descending bool) *procBuilder {
 	if descending {
@@ -84,10 +188,7 @@ func (s *procBuilder) NoReadOnly() *procBuilder {
 
 // Build generates the select SQL along with meta information.
 func (s *procBuilder) Build(terminateWithSemiColon bool) StatementInfo {
-	paramFormat := os.Getenv("DATABASE_TYPE")
-	switch paramFormat {
-	// case DbTypePostgreSQL:
-	// 	return s.buildForPgSQL(terminateWithSemiColon, 0)
+	switch s.dbtype {
 	case DbTypePostgreSQL:
 		return s.buildForPgSQL(terminateWithSemiColon, 0)
 	default:
@@ -97,8 +198,7 @@ func (s *procBuilder) Build(terminateWithSemiColon bool) StatementInfo {
 
 func (s *procBuilder) buildForMsAndMySQL(terminateWithSemiColon bool, startParam int) StatementInfo {
 	var sql strings.Builder
-	s.paramCounter = startParam
-	s.fieldCounter = 0
+	s.reset(startParam)
 
 	cnt := len(s.selectsql)
 	if cnt < 1 && !s.perform {
@@ -106,6 +206,9 @@ func (s *procBuilder) buildForMsAndMySQL(terminateWithSemiColon bool, startParam
 	}
 
 	if s.dbtype == DbTypeMySQL {
+		if s.namedArgs {
+			panic("named arguments are not applicable to mysql stored procedures")
+		}
 		sql.WriteString("call ")
 	} else {
 		sql.WriteString("exec ")
@@ -123,7 +226,11 @@ func (s *procBuilder) buildForMsAndMySQL(terminateWithSemiColon bool, startParam
 	}
 
 	sql.WriteString(s.proc)
-	sql.Write(space)
+	if s.dbtype == DbTypeMySQL {
+		sql.Write(openbrace)
+	} else if len(s.args) > 0 {
+		sql.Write(space)
+	}
 
 	// add parameters
 	for i, arg := range s.args {
@@ -131,11 +238,37 @@ func (s *procBuilder) buildForMsAndMySQL(terminateWithSemiColon bool, startParam
 			sql.Write(comma)
 		}
 
+		if s.dbtype == DbTypeMySQL && arg.direction != argIn {
+			// out params are returned through session variables
+			sql.WriteString("@" + arg.name)
+			s.addOutParamCSV(arg.name)
+			continue
+		}
+
+		if arg.direction == argExpr {
+			s.writeParamSQL(&sql, arg.name, arg.name)
+			continue
+		}
+		if s.namedArgs {
+			sql.WriteString("@" + arg.name + "=")
+		}
 		sql.WriteString(s.paramChar)
 		if s.paramNumeric {
 			sql.WriteString(strconv.Itoa(s.paramCounter + 1))
 		}
-		s.addParamToCSV(arg)
+		s.addParamToCSV(arg.name)
+
+		if arg.direction != argIn {
+			sql.WriteString(" output")
+			s.addOutParamCSV(arg.name)
+		}
+	}
+	if s.dbtype == DbTypeMySQL {
+		sql.Write(closebrace)
+	}
+
+	if len(s.conditionGroups) > 0 {
+		panic("where clause is not applicable to mssql/mysql stored procedures")
 	}
 
 	// add order by
@@ -154,6 +287,7 @@ func (s *procBuilder) buildForMsAndMySQL(terminateWithSemiColon bool, startParam
 	stmt := StatementInfo{}
 	stmt.ParamCount = s.paramCounter
 	stmt.ParamFields = s.paramCsv.String()
+	stmt.OutParamFields = s.outParamCsv.String()
 	stmt.Fields = s.fieldCsv.String()
 	stmt.FieldsCount = s.fieldCounter
 	stmt.SQL = sql.String()
@@ -161,18 +295,100 @@ func (s *procBuilder) buildForMsAndMySQL(terminateWithSemiColon bool, startParam
 	return stmt
 }
 
+//writeSource writes function call along with its arguments, alias and column definitions
+func (s *procBuilder) writeSource(sql *strings.Builder) {
+	sql.WriteString(s.proc)
+	sql.Write(openbrace)
+
+	// add parameters
+	i := 0
+	for _, arg := range s.args {
+		if arg.direction == argOut && !s.call {
+			// OUT params are not passed to function, they are returned as result columns
+			s.addOutParamCSV(arg.name)
+			continue
+		}
+		if i > 0 {
+			sql.Write(comma)
+		}
+		i++
+
+		if arg.direction == argOut {
+			// procedure takes OUT params as null, their values are returned as result row
+			if s.namedArgs {
+				sql.WriteString(arg.name + " => ")
+			}
+			sql.WriteString("null")
+			s.addOutParamCSV(arg.name)
+			continue
+		}
+
+		if arg.direction == argExpr {
+			s.writeParamSQL(sql, arg.name, arg.name)
+			continue
+		}
+		if s.namedArgs {
+			sql.WriteString(arg.name + " => ")
+		}
+		sql.WriteString(s.paramChar)
+		if s.paramNumeric {
+			sql.WriteString(strconv.Itoa(s.paramCounter + 1))
+		}
+		s.addParamToCSV(arg.name)
+
+		if arg.direction == argInOut {
+			s.addOutParamCSV(arg.name)
+		}
+	}
+	sql.Write(closebrace)
+
+	if s.alias != "" {
+		sql.WriteString(" as ")
+		sql.WriteString(s.alias)
+		if len(s.columnDefs) > 0 {
+			sql.Write(openbrace)
+			sql.WriteString(strings.Join(s.columnDefs, ", "))
+			sql.Write(closebrace)
+		}
+	}
+}
+
+//buildSource generates function call to be used as table source in SelectBuilder.
+//Select-list, ordering and limit of proc are not used here.
+func (s *procBuilder) buildSource(startParam int) StatementInfo {
+	if s.dbtype == DbTypeMySQL {
+		panic("table functions are not applicable to mysql")
+	}
+	if s.perform || s.proc == "" {
+		panic("table source requires proc set by FromProc")
+	}
+
+	var sql strings.Builder
+	s.reset(startParam)
+	s.writeSource(&sql)
+
+	stmt := StatementInfo{}
+	stmt.ParamCount = s.paramCounter
+	stmt.ParamFields = s.paramCsv.String()
+	stmt.OutParamFields = s.outParamCsv.String()
+	stmt.SQL = sql.String()
+	return stmt
+}
+
 func (s *procBuilder) buildForPgSQL(terminateWithSemiColon bool, startParam int) StatementInfo {
 	var sql strings.Builder
-	s.paramCounter = startParam
-	s.fieldCounter = 0
+	s.reset(startParam)
 
 	cnt := len(s.selectsql)
 	if cnt < 1 && !s.perform {
 		return StatementInfo{SQL: "no fields to select"}
 	}
 
-	if s.perform {
-		sql.WriteString("perform")
+	if s.call {
+		sql.WriteString("call ")
+	} else if s.perform {
+		// perform is valid inside PL/pgSQL only, select and discard result instead
+		sql.WriteString("select ")
 	} else {
 		sql.WriteString("select ")
 		for i, sSQL := range s.selectsql {
@@ -182,34 +398,24 @@ func (s *procBuilder) buildForPgSQL(terminateWithSemiColon bool, startParam int)
 			sql.WriteString(sSQL)
 			s.addFieldToCSV(sSQL)
 		}
-	}
 
-	if s.rowcount && !s.perform {
-		sql.WriteString(", count(*) over() as rowscount")
-		s.addFieldToCSV("rowscount")
-	}
+		if s.rowcount {
+			sql.WriteString(", count(*) over() as rowscount")
+			s.addFieldToCSV("rowscount")
+		}
 
-	sql.Write(space)
-	if !s.perform {
-		sql.WriteString("from")
-		sql.Write(space)
+		sql.WriteString(" from ")
 	}
-	sql.WriteString(s.proc)
-	sql.Write(openbrace)
-
-	// add parameters
-	for i, arg := range s.args {
-		if i > 0 {
-			sql.Write(comma)
-		}
+	s.writeSource(&sql)
 
-		sql.WriteString(s.paramChar)
-		if s.paramNumeric {
-			sql.WriteString(strconv.Itoa(s.paramCounter + 1))
+	// get where clause, its parameters continue after function arguments
+	if len(s.conditionGroups) > 0 {
+		if s.perform {
+			panic("where clause is not applicable to perform or call")
 		}
-		s.addParamToCSV(arg)
+		sql.Write(space)
+		sql.WriteString(s.getWhereClause())
 	}
-	sql.Write(closebrace)
 
 	// add order by
 	if len(s.orderBy) > 0 {
@@ -235,6 +441,7 @@ func (s *procBuilder) buildForPgSQL(terminateWithSemiColon bool, startParam int)
 	stmt := StatementInfo{}
 	stmt.ParamCount = s.paramCounter
 	stmt.ParamFields = s.paramCsv.String()
+	stmt.OutParamFields = s.outParamCsv.String()
 	stmt.Fields = s.fieldCsv.String()
 	stmt.FieldsCount = s.fieldCounter
 	stmt.SQL = sql.String()
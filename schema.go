@@ -0,0 +1,730 @@
+package gosql
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema holds tables of database to validate statements against.
+// Builders bound to a schema by their Schema method, or queued to FileWriter having a schema, report unknown
+// tables, columns and aliases, type mismatches in conditions and missing required columns of inserts when built.
+type Schema struct {
+	tables map[string]*Table
+	names  []string
+}
+
+// Table describes a table or view of Schema.
+type Table struct {
+	Name       string
+	Columns    []Column
+	PrimaryKey []string
+	// Unique, Checks and ForeignKeys are constraints of table, including those declared with a column.
+	Unique      []UniqueKey
+	Checks      []Check
+	ForeignKeys []ForeignKey
+	// View is true for views, they are validated like tables but cannot be inserted into.
+	View bool
+}
+
+// UniqueKey is unique constraint on columns of Table. Name is empty when constraint is not named.
+type UniqueKey struct {
+	Name    string
+	Columns []string
+}
+
+// Check is check constraint of Table with its SQL expression e.g. 'marks > 0'.
+type Check struct {
+	Name string
+	Expr string
+}
+
+// ForeignKey is foreign key on columns of Table referencing RefColumns of RefTable.
+// RefColumns are empty when primary key of RefTable is referenced. OnDelete and OnUpdate are actions
+// like 'cascade' or 'set null', empty when not given.
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string
+	OnUpdate   string
+}
+
+// Column describes a column of Table. Type is SQL type as declared e.g. 'varchar(50)' or 'bigint'.
+type Column struct {
+	Name     string
+	Type     string
+	NotNull  bool
+	Default  string
+	Identity bool // value is generated by database e.g. serial, identity or auto_increment
+	Computed bool // value is computed from expression e.g. GENERATED ALWAYS AS (expr) STORED, it cannot be written
+	// PrimaryKey adds column to primary key of table, primary key columns are not null.
+	PrimaryKey bool
+}
+
+// SchemaErrors holds all problems found by validating a statement against Schema.
+// Build of a builder bound to schema panics with SchemaErrors, FileWriter reports them per statement.
+type SchemaErrors []string
+
+func (e SchemaErrors) Error() string {
+	return strings.Join(e, "; ")
+}
+
+// NewSchema creates empty Schema.
+func NewSchema() *Schema {
+	return &Schema{tables: make(map[string]*Table)}
+}
+
+// AddTable adds table with given columns to schema, it replaces table of same name if any.
+func (s *Schema) AddTable(name string, cols ...Column) *Table {
+	t := &Table{Name: name}
+	for _, c := range cols {
+		t.AddColumn(c)
+	}
+	s.Add(t)
+	return t
+}
+
+// Add adds given table to schema, it replaces table of same name if any.
+func (s *Schema) Add(t *Table) {
+	key := strings.ToLower(t.Name)
+	if _, ok := s.tables[key]; !ok {
+		s.names = append(s.names, key)
+	}
+	s.tables[key] = t
+}
+
+// Drop removes table of given name from schema.
+func (s *Schema) Drop(name string) {
+	key := strings.ToLower(name)
+	if _, ok := s.tables[key]; !ok {
+		return
+	}
+	delete(s.tables, key)
+	for i, n := range s.names {
+		if n == key {
+			s.names = append(s.names[:i], s.names[i+1:]...)
+			break
+		}
+	}
+}
+
+// Lookup returns table of given name, or nil if schema has no such table.
+// Names are case-insensitive, name qualified by database schema like 'dbo.users' also matches table 'users'.
+func (s *Schema) Lookup(name string) *Table {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if t, ok := s.tables[key]; ok {
+		return t
+	}
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		return s.tables[key[i+1:]]
+	}
+	return nil
+}
+
+// Tables returns all tables of schema in order they were added.
+func (s *Schema) Tables() []*Table {
+	tables := make([]*Table, len(s.names))
+	for i, n := range s.names {
+		tables[i] = s.tables[n]
+	}
+	return tables
+}
+
+// AddColumn adds column to table, it replaces column of same name if any.
+func (t *Table) AddColumn(c Column) {
+	if c.PrimaryKey {
+		c.NotNull = true
+		if !containsFold(t.PrimaryKey, c.Name) {
+			t.PrimaryKey = append(t.PrimaryKey, c.Name)
+		}
+	}
+	if old := t.Column(c.Name); old != nil {
+		*old = c
+		return
+	}
+	t.Columns = append(t.Columns, c)
+}
+
+// DropColumn removes column of given name from table, along with unique keys and foreign keys on it.
+func (t *Table) DropColumn(name string) {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			t.Columns = append(t.Columns[:i], t.Columns[i+1:]...)
+			break
+		}
+	}
+	var unique []UniqueKey
+	for _, u := range t.Unique {
+		if !containsFold(u.Columns, name) {
+			unique = append(unique, u)
+		}
+	}
+	t.Unique = unique
+	var fks []ForeignKey
+	for _, fk := range t.ForeignKeys {
+		if !containsFold(fk.Columns, name) {
+			fks = append(fks, fk)
+		}
+	}
+	t.ForeignKeys = fks
+}
+
+// DropConstraint removes unique key, check or foreign key of given name from table.
+// It returns false if table has no such constraint.
+func (t *Table) DropConstraint(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, u := range t.Unique {
+		if strings.EqualFold(u.Name, name) {
+			t.Unique = append(t.Unique[:i], t.Unique[i+1:]...)
+			return true
+		}
+	}
+	for i, c := range t.Checks {
+		if strings.EqualFold(c.Name, name) {
+			t.Checks = append(t.Checks[:i], t.Checks[i+1:]...)
+			return true
+		}
+	}
+	for i, fk := range t.ForeignKeys {
+		if strings.EqualFold(fk.Name, name) {
+			t.ForeignKeys = append(t.ForeignKeys[:i], t.ForeignKeys[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Column returns column of given name, or nil if table has no such column. Names are case-insensitive.
+func (t *Table) Column(name string) *Column {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// Required tells whether column must be given on insert, i.e. it is not null and database does not generate its value.
+func (c *Column) Required() bool {
+	if !c.NotNull || c.Default != "" || c.Identity || c.Computed {
+		return false
+	}
+	return !strings.Contains(strings.ToLower(c.Type), "serial")
+}
+
+// Validate checks statement of given builder against schema, it returns SchemaErrors or nil.
+// Builders of stored procedures are not validated, as schema does not describe procedures.
+func (s *Schema) Validate(b Builder) error {
+	v := validator{schema: s, seen: make(map[string]bool)}
+	switch b := b.(type) {
+	case *selectBuilder:
+		v.selectBuilder(b, nil)
+	case *insertBuilder:
+		v.insertBuilder(b)
+	case *updateBuilder:
+		v.updateBuilder(b)
+	case *deleteBuilder:
+		v.deleteBuilder(b)
+	}
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// Schema binds builder to given schema, Build panics with SchemaErrors when statement does not match schema.
+func (s *selectBuilder) Schema(schema *Schema) *selectBuilder {
+	s.schema = schema
+	return s
+}
+
+// Schema binds builder to given schema, Build panics with SchemaErrors when statement does not match schema.
+func (n *insertBuilder) Schema(schema *Schema) *insertBuilder {
+	n.schema = schema
+	return n
+}
+
+// Schema binds builder to given schema, Build panics with SchemaErrors when statement does not match schema.
+func (u *updateBuilder) Schema(schema *Schema) *updateBuilder {
+	u.schema = schema
+	return u
+}
+
+// Schema binds builder to given schema, Build panics with SchemaErrors when statement does not match schema.
+func (u *deleteBuilder) Schema(schema *Schema) *deleteBuilder {
+	u.schema = schema
+	return u
+}
+
+// defaultSchema binds builder to given schema unless it is bound to one already.
+func (b *builder) defaultSchema(schema *Schema) {
+	if b.schema == nil {
+		b.schema = schema
+	}
+}
+
+// validateSchema panics with SchemaErrors if builder is bound to schema and its statement does not match it.
+func (b *builder) validateSchema(self Builder) {
+	if b.schema == nil {
+		return
+	}
+	if err := b.schema.Validate(self); err != nil {
+		panic(err)
+	}
+}
+
+// scope holds sources of a statement by alias, sub-sqls see sources of their parents.
+// Source is nil when its columns are not known e.g. derived tables.
+type scope struct {
+	parent  *scope
+	sources map[string]*Table
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{parent, make(map[string]*Table)}
+}
+
+// source returns source of given alias and whether it exists in scope or its parents
+func (sc *scope) source(alias string) (*Table, bool) {
+	for ; sc != nil; sc = sc.parent {
+		if t, ok := sc.sources[strings.ToLower(alias)]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// column returns column of given unqualified name from sources of scope or its parents.
+// It returns true if column is found, or cannot be checked as a source has unknown columns.
+func (sc *scope) column(name string) (*Column, bool) {
+	for ; sc != nil; sc = sc.parent {
+		open := false
+		for _, t := range sc.sources {
+			if t == nil {
+				open = true
+			} else if c := t.Column(name); c != nil {
+				return c, true
+			}
+		}
+		if open {
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+type validator struct {
+	schema *Schema
+	errs   SchemaErrors
+	seen   map[string]bool
+}
+
+func (v *validator) errorf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if !v.seen[msg] {
+		v.seen[msg] = true
+		v.errs = append(v.errs, msg)
+	}
+}
+
+// table returns table of given name from schema, unknown table is reported
+func (v *validator) table(name string) *Table {
+	t := v.schema.Lookup(name)
+	if t == nil {
+		v.errorf("unknown table '%s'", name)
+	}
+	return t
+}
+
+// tableColumns reports columns missing in given table
+func (v *validator) tableColumns(t *Table, cols []string) {
+	if t == nil {
+		return
+	}
+	for _, col := range cols {
+		if t.Column(col) == nil {
+			v.errorf("unknown column '%s' of table '%s'", col, t.Name)
+		}
+	}
+}
+
+func (v *validator) selectBuilder(s *selectBuilder, parent *scope) {
+	sc := newScope(parent)
+	for _, alias := range sortedAliases(s.tables) {
+		from := s.tables[alias]
+		switch {
+		case from.proc != nil:
+			sc.sources[strings.ToLower(from.proc.alias)] = procSource(from.proc)
+		case from.subBuilder != nil:
+			v.selectBuilder(from.subBuilder, parent)
+			sc.sources[strings.ToLower(alias)] = nil
+		default:
+			t := v.table(from.table)
+			if alias == "" {
+				alias = from.table
+			}
+			// columns of unknown table are not checked
+			sc.sources[strings.ToLower(alias)] = t
+		}
+	}
+	for _, js := range s.joinsql {
+		if js.proc != nil {
+			sc.sources[strings.ToLower(js.proc.alias)] = procSource(js.proc)
+		} else {
+			// lateral sub-sql sees sources added before it
+			v.selectBuilder(js.subBuilder, sc)
+			sc.sources[strings.ToLower(js.alias)] = nil
+		}
+		v.conditions(js.conditions, sc)
+	}
+
+	for _, sq := range s.selectsql {
+		switch {
+		case sq.subBuilder != nil:
+			v.selectBuilder(sq.subBuilder, sc)
+		case sq.caseExpr != nil:
+			v.caseExpr(sq.caseExpr, sc)
+		default:
+			v.expr(sq.sql, sc, true)
+		}
+	}
+	v.where(s.conditionGroups, sc)
+	for _, col := range s.distinctOn {
+		v.expr(col, sc, true)
+	}
+	for _, col := range s.groupBy {
+		v.expr(col, sc, true)
+	}
+	for _, set := range s.groupingSets {
+		for _, col := range set {
+			v.expr(col, sc, true)
+		}
+	}
+	for _, o := range s.orderBy {
+		if o.caseExpr != nil {
+			v.caseExpr(o.caseExpr, sc)
+		} else {
+			// ordering may refer to aliases of select-list, only qualified columns are checked
+			v.expr(o.expr, sc, false)
+		}
+	}
+}
+
+func (v *validator) insertBuilder(n *insertBuilder) {
+	t := v.table(n.table)
+	if t == nil {
+		return
+	}
+	if t.View {
+		v.errorf("cannot insert into view '%s'", t.Name)
+	}
+	v.tableColumns(t, n.fields)
+	v.tableColumns(t, n.returningFields)
+	v.writable(t, n.fields)
+	for _, c := range t.Columns {
+		if c.Required() && !containsFold(n.fields, c.Name) {
+			v.errorf("missing required column '%s' of table '%s'", c.Name, t.Name)
+		}
+	}
+}
+
+func (v *validator) updateBuilder(u *updateBuilder) {
+	t := v.table(u.table)
+	sc := v.tableScope(u.table, t)
+	v.tableColumns(t, u.fields)
+	v.tableColumns(t, u.returningFields)
+	for _, cc := range u.calcfields {
+		v.tableColumns(t, []string{cc.col})
+		v.expr(cc.value, sc, false)
+	}
+	for _, cc := range u.casefields {
+		v.tableColumns(t, []string{cc.col})
+		v.caseExpr(cc.expr, sc)
+	}
+	v.writable(t, u.fields)
+	for _, cc := range u.calcfields {
+		v.writable(t, []string{cc.col})
+	}
+	for _, cc := range u.casefields {
+		v.writable(t, []string{cc.col})
+	}
+	v.where(u.conditionGroups, sc)
+}
+
+// writable reports computed columns among given columns of table, which are written by statement
+func (v *validator) writable(t *Table, cols []string) {
+	if t == nil {
+		return
+	}
+	for _, col := range cols {
+		if c := t.Column(col); c != nil && c.Computed {
+			v.errorf("cannot write computed column '%s' of table '%s'", c.Name, t.Name)
+		}
+	}
+}
+
+func (v *validator) deleteBuilder(u *deleteBuilder) {
+	t := v.table(u.table)
+	v.tableColumns(t, u.returningFields)
+	v.where(u.conditionGroups, v.tableScope(u.table, t))
+}
+
+// tableScope returns scope having only given table, for update and delete statements
+func (v *validator) tableScope(name string, t *Table) *scope {
+	sc := newScope(nil)
+	sc.sources[strings.ToLower(name)] = t
+	return sc
+}
+
+func (v *validator) where(groups map[int]conditionGroup, sc *scope) {
+	keys := make([]int, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	for _, k := range keys {
+		v.conditions(groups[k].conditions, sc)
+	}
+}
+
+func (v *validator) caseExpr(c *CaseExpr, sc *scope) {
+	var conds []Condition
+	for _, w := range c.whens {
+		conds = append(conds, w.condition)
+		v.expr(w.value, sc, false)
+	}
+	v.conditions(conds, sc)
+	v.expr(c.elseValue, sc, false)
+}
+
+func (v *validator) conditions(conds []Condition, sc *scope) {
+	for _, cond := range conds {
+		if cond.subBuilder != nil {
+			// conditionsql holds operator only
+			v.expr(cond.fieldname, sc, true)
+			v.selectBuilder(cond.subBuilder, sc)
+			continue
+		}
+		v.expr(cond.conditionsql, sc, false)
+		if !strings.Contains(cond.fieldname, ".") {
+			v.expr(cond.fieldname, sc, true)
+		}
+		v.conditionTypes(cond, sc)
+	}
+}
+
+var (
+	// qualifiedRef matches references like 'q.TopicID', function calls like 'pg_catalog.now(' are excluded by caller
+	qualifiedRef = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\.([A-Za-z_][A-Za-z0-9_]*)(\s*\()?`)
+	identifier   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	quoted       = regexp.MustCompile(`'(?:[^']|'')*'`)
+	// selectAlias matches trailing alias of select-list item
+	selectAlias = regexp.MustCompile(`(?i)\s+(as\s+)?[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// expr reports unknown aliases and columns referred by given expression.
+// If bare is true and expression is a single unqualified name, that name must be a column of sources in scope.
+func (v *validator) expr(expr string, sc *scope, bare bool) {
+	expr = strings.TrimSpace(quoted.ReplaceAllString(expr, "''"))
+	if expr == "" {
+		return
+	}
+	for _, m := range qualifiedRef.FindAllStringSubmatch(expr, -1) {
+		if m[3] != "" {
+			continue
+		}
+		v.qualified(m[1], m[2], sc)
+	}
+	if !bare {
+		return
+	}
+	name := expr
+	if !identifier.MatchString(name) {
+		name = selectAlias.ReplaceAllString(expr, "")
+	}
+	if identifier.MatchString(name) && !isKeyword(name) {
+		if _, ok := sc.column(name); !ok {
+			v.errorf("unknown column '%s'", name)
+		}
+	}
+}
+
+// qualified reports unknown alias or column of reference alias.col
+func (v *validator) qualified(alias, col string, sc *scope) {
+	t, ok := sc.source(alias)
+	if !ok {
+		v.errorf("unknown alias '%s' in '%s.%s'", alias, alias, col)
+		return
+	}
+	if t != nil && t.Column(col) == nil {
+		v.errorf("unknown column '%s' of table '%s'", col, t.Name)
+	}
+}
+
+// resolve returns column referred by given operand, or nil if operand is not a known column
+func (v *validator) resolve(operand string, sc *scope) *Column {
+	operand = strings.TrimSpace(operand)
+	if m := qualifiedRef.FindStringSubmatch(operand); m != nil && m[0] == operand {
+		if t, _ := sc.source(m[1]); t != nil {
+			return t.Column(m[2])
+		}
+		return nil
+	}
+	if identifier.MatchString(operand) && !isKeyword(operand) {
+		c, _ := sc.column(operand)
+		return c
+	}
+	return nil
+}
+
+var comparisonOps = []string{"!=", "<>", ">=", "<=", "=", ">", "<"}
+
+// conditionTypes reports operands of condition whose types cannot be compared with its field
+func (v *validator) conditionTypes(cond Condition, sc *scope) {
+	col := v.resolve(cond.fieldname, sc)
+	if col == nil || !strings.HasPrefix(cond.conditionsql, cond.fieldname) {
+		return
+	}
+	rest := cond.conditionsql[len(cond.fieldname):]
+
+	var operands []string
+	lower := strings.ToLower(rest)
+	switch {
+	case strings.HasPrefix(lower, " between "):
+		operands = strings.SplitN(rest[len(" between "):], " and ", 2)
+	case strings.HasPrefix(lower, " in (") && strings.HasSuffix(rest, ")"):
+		operands = splitCSV(rest[len(" in (") : len(rest)-1])
+	default:
+		for _, op := range comparisonOps {
+			if strings.HasPrefix(rest, op) {
+				if !strings.HasPrefix(strings.ToLower(rest[len(op):]), "any(") {
+					operands = []string{rest[len(op):]}
+				}
+				break
+			}
+		}
+	}
+
+	class := typeClass(col.Type)
+	for _, operand := range operands {
+		operand = strings.TrimSpace(operand)
+		other, otherType := "", operand
+		if c := v.resolve(operand, sc); c != nil {
+			other, otherType = typeClass(c.Type), c.Type
+		} else {
+			other = literalClass(operand, class)
+		}
+		if !compatibleClass(class, other) {
+			v.errorf("type mismatch in '%s': %s compared with %s", cond.conditionsql, col.Type, otherType)
+			return
+		}
+	}
+}
+
+// typeClass returns kind of values of given SQL type, values of same kind can be compared
+func typeClass(sqltype string) string {
+	t := strings.ToLower(strings.TrimSpace(sqltype))
+	if i := strings.IndexAny(t, "( "); i >= 0 {
+		t = t[:i]
+	}
+	switch t {
+	case "int", "integer", "int2", "int4", "int8", "smallint", "bigint", "tinyint", "mediumint",
+		"serial", "smallserial", "bigserial", "serial4", "serial8",
+		"decimal", "numeric", "real", "float", "float4", "float8", "double", "money", "smallmoney":
+		return "number"
+	case "bool", "boolean", "bit":
+		return "bool"
+	case "char", "character", "varchar", "nchar", "nvarchar", "text", "ntext", "tinytext", "mediumtext",
+		"longtext", "citext", "string", "enum":
+		return "text"
+	case "date", "time", "timetz", "timestamp", "timestamptz", "datetime", "datetime2", "smalldatetime",
+		"datetimeoffset", "interval", "year":
+		return "time"
+	}
+	return ""
+}
+
+// literalClass returns kind of given literal operand, or empty string if it is not a literal.
+// Quoted literal is accepted by columns of any kind if its value can be converted, as databases do.
+func literalClass(operand, colClass string) string {
+	switch {
+	case operand == "" || len(splitParams(operand)) > 1:
+		return ""
+	case strings.HasPrefix(operand, "'") && strings.HasSuffix(operand, "'") && len(operand) > 1:
+		value := operand[1 : len(operand)-1]
+		switch colClass {
+		case "number":
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return "text"
+			}
+		case "bool":
+			if _, err := strconv.ParseBool(value); err != nil {
+				return "text"
+			}
+		}
+		return ""
+	case strings.EqualFold(operand, "true") || strings.EqualFold(operand, "false"):
+		return "bool"
+	}
+	if _, err := strconv.ParseFloat(operand, 64); err == nil {
+		return "number"
+	}
+	return ""
+}
+
+// compatibleClass tells whether values of given kinds can be compared, unknown kinds are compatible with all
+func compatibleClass(a, b string) bool {
+	if a == "" || b == "" || a == b {
+		return true
+	}
+	// bit columns are compared with 0 and 1
+	return a == "bool" && b == "number"
+}
+
+// procSource returns table of columns defined by proc alias, or nil when columns are not known
+func procSource(proc *procBuilder) *Table {
+	if len(proc.columnDefs) == 0 {
+		return nil
+	}
+	t := &Table{Name: proc.alias}
+	for _, def := range proc.columnDefs {
+		parts := strings.Fields(def)
+		if len(parts) > 0 {
+			t.AddColumn(Column{Name: parts[0], Type: strings.Join(parts[1:], " ")})
+		}
+	}
+	return t
+}
+
+var sqlKeywords = map[string]bool{
+	"null": true, "true": true, "false": true, "default": true, "current_timestamp": true, "current_date": true,
+	"current_time": true, "current_user": true, "localtime": true, "localtimestamp": true,
+}
+
+func isKeyword(name string) bool {
+	return sqlKeywords[strings.ToLower(name)]
+}
+
+func sortedAliases(tables map[string]fromSQL) []string {
+	aliases := make([]string, 0, len(tables))
+	for alias := range tables {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
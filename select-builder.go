@@ -10,6 +10,38 @@ type selectSQL struct {
 	//issub      bool
 	sql        string
 	subBuilder *selectBuilder // for sub-sql builing
+	fieldname  string         // name to record in Fields, sql is used when empty
+	caseExpr   *CaseExpr
+}
+
+type fromSQL struct {
+	table      string
+	subBuilder *selectBuilder // for derived tables
+	proc       *procBuilder   // for table functions
+}
+
+type groupingType int
+
+const (
+	groupingNone groupingType = iota
+	groupingRollup
+	groupingCube
+	groupingSets
+)
+
+type joinType int
+
+const (
+	joinLateral joinType = iota
+	joinLeftLateral
+)
+
+type joinSQL struct {
+	jointype   joinType
+	alias      string
+	subBuilder *selectBuilder
+	proc       *procBuilder
+	conditions []Condition
 }
 
 //var _usePgArray bool
@@ -18,7 +50,7 @@ type selectSQL struct {
 // It allows to generate SELECT sql statements.
 func SelectBuilder() *selectBuilder {
 	s := selectBuilder{}
-	s.tables = make(map[string]string)
+	s.tables = make(map[string]fromSQL)
 	s.conditionGroups = make(map[int]conditionGroup)
 	s.limitRows = 0
 	s.readonly = true
@@ -29,7 +61,7 @@ func SelectBuilder() *selectBuilder {
 // Select specifies the fields for select clause.
 func (s *selectBuilder) Select(fields ...string) *selectBuilder {
 	for _, v := range fields {
-		sql := selectSQL{strings.Trim(v, " "), nil}
+		sql := selectSQL{strings.Trim(v, " "), nil, "", nil}
 		s.selectsql = append(s.selectsql, sql)
 	}
 	return s
@@ -37,7 +69,14 @@ func (s *selectBuilder) Select(fields ...string) *selectBuilder {
 
 // Sub allows to creates sub-sql. It returns new instance of SelectBuilder.
 func (s *selectBuilder) Sub(builder *selectBuilder, colAlias string) *selectBuilder {
-	sq := selectSQL{colAlias, builder}
+	sq := selectSQL{colAlias, builder, "", nil}
+	s.selectsql = append(s.selectsql, sq)
+	return s
+}
+
+// SelectCase adds CASE expression with given alias to select-list. Only alias is recorded in Fields.
+func (s *selectBuilder) SelectCase(c *CaseExpr, alias string) *selectBuilder {
+	sq := selectSQL{"as " + alias, nil, alias, c}
 	s.selectsql = append(s.selectsql, sq)
 	return s
 }
@@ -46,8 +85,81 @@ func (s *selectBuilder) Sub(builder *selectBuilder, colAlias string) *selectBuil
 // It adds table that is being used in sql, also allow to use table name alias.
 func (s *selectBuilder) From(tblname, alias string) *selectBuilder {
 	if tblname != "" {
-		s.tables[alias] = strings.ToLower(tblname)
+		s.tables[alias] = fromSQL{strings.ToLower(tblname), nil, nil}
+	}
+	return s
+}
+
+// FromSub adds a derived table (sub-sql) to the FROM clause of sql.
+// Alias is mandatory as derived tables must be named.
+func (s *selectBuilder) FromSub(builder *selectBuilder, alias string) *selectBuilder {
+	if builder == nil || alias == "" {
+		panic("derived table requires sub builder and alias")
 	}
+	s.tables[alias] = fromSQL{"", builder, nil}
+	return s
+}
+
+// JoinLateral joins a sub-sql that can refer to columns of preceding FROM tables,
+// e.g. to select top-N rows per group.
+//
+// It renders as 'cross join lateral' on PostgreSQL and MySQL, or as 'join lateral ... on' when
+// conditions are given. On MS-SQL it renders as 'cross apply', where conditions are applied to result of sub-sql.
+func (s *selectBuilder) JoinLateral(builder *selectBuilder, alias string, on ...ICondition) *selectBuilder {
+	if builder == nil || alias == "" {
+		panic("lateral join requires sub builder and alias")
+	}
+	return s.addJoin(joinSQL{jointype: joinLateral, alias: alias, subBuilder: builder}, on)
+}
+
+// LeftJoinLateral is like JoinLateral but keeps rows of preceding FROM tables for which sub-sql returns no rows.
+//
+// It renders as 'left join lateral ... on' on PostgreSQL and MySQL, and as 'outer apply' on MS-SQL.
+func (s *selectBuilder) LeftJoinLateral(builder *selectBuilder, alias string, on ...ICondition) *selectBuilder {
+	if builder == nil || alias == "" {
+		panic("lateral join requires sub builder and alias")
+	}
+	return s.addJoin(joinSQL{jointype: joinLeftLateral, alias: alias, subBuilder: builder}, on)
+}
+
+// FromProc adds call of table function to the FROM clause of sql, alias must be set on proc by As().
+// For example
+//
+//	FromProc(ProcBuilder().FromProc("getquestions").Param("topicid").As("q"))
+//
+// Parameters of function are numbered in continuation of select-list, and conditions continue after them.
+// Select-list, ordering and limit of proc are not used.
+func (s *selectBuilder) FromProc(proc *procBuilder) *selectBuilder {
+	if proc == nil || proc.alias == "" {
+		panic("table function requires proc with alias")
+	}
+	s.tables[proc.alias] = fromSQL{"", nil, proc}
+	return s
+}
+
+// JoinProc joins call of table function that can take columns of preceding FROM tables as arguments,
+// alias must be set on proc by As(). It renders like JoinLateral.
+func (s *selectBuilder) JoinProc(proc *procBuilder, on ...ICondition) *selectBuilder {
+	if proc == nil || proc.alias == "" {
+		panic("table function requires proc with alias")
+	}
+	return s.addJoin(joinSQL{jointype: joinLateral, alias: proc.alias, proc: proc}, on)
+}
+
+// LeftJoinProc is like JoinProc but keeps rows of preceding FROM tables for which function returns no rows.
+func (s *selectBuilder) LeftJoinProc(proc *procBuilder, on ...ICondition) *selectBuilder {
+	if proc == nil || proc.alias == "" {
+		panic("table function requires proc with alias")
+	}
+	return s.addJoin(joinSQL{jointype: joinLeftLateral, alias: proc.alias, proc: proc}, on)
+}
+
+func (s *selectBuilder) addJoin(js joinSQL, on []ICondition) *selectBuilder {
+	js.conditions = make([]Condition, 0, len(on))
+	for _, cd := range on {
+		js.conditions = append(js.conditions, cd.(Condition))
+	}
+	s.joinsql = append(s.joinsql, js)
 	return s
 }
 
@@ -101,17 +213,79 @@ func (s *selectBuilder) GroupBy(fields ...string) *selectBuilder {
 	return s
 }
 
+// GroupByRollup adds ROLLUP of given fields to GROUP BY clause, generating subtotals for each level of fields
+// and a grand total.
+// On MySQL it renders as 'group by ... with rollup' and cannot be combined with GroupBy.
+func (s *selectBuilder) GroupByRollup(fields ...string) *selectBuilder {
+	s.grouping = groupingRollup
+	s.groupingSets = [][]string{trimAll(fields)}
+	return s
+}
+
+// GroupByCube adds CUBE of given fields to GROUP BY clause, generating subtotals for all combinations of fields.
+// It is not supported by MySQL.
+func (s *selectBuilder) GroupByCube(fields ...string) *selectBuilder {
+	s.grouping = groupingCube
+	s.groupingSets = [][]string{trimAll(fields)}
+	return s
+}
+
+// GroupingSets adds GROUPING SETS to GROUP BY clause. Each set is list of fields, an empty set gives grand total.
+// It is not supported by MySQL.
+// For example
+//
+//	GroupingSets([]string{"year", "month"}, []string{"year"}, []string{})
+func (s *selectBuilder) GroupingSets(sets ...[]string) *selectBuilder {
+	s.grouping = groupingSets
+	s.groupingSets = make([][]string, 0, len(sets))
+	for _, set := range sets {
+		s.groupingSets = append(s.groupingSets, trimAll(set))
+	}
+	return s
+}
+
+// Grouping adds 'grouping(col) as alias' to select-list, it tells whether the row is a subtotal over given column.
+// Only alias is recorded in Fields.
+func (s *selectBuilder) Grouping(col, alias string) *selectBuilder {
+	sq := selectSQL{concat("grouping(", strings.Trim(col, " "), ") as ", alias), nil, alias, nil}
+	s.selectsql = append(s.selectsql, sq)
+	return s
+}
+
 // OrderBy specifies the ORDER BY clause of sql. Different fields may have different ordering (asc or desc).
 func (s *selectBuilder) OrderBy(fieldname string, descending bool) *selectBuilder {
-	if descending {
-		s.orderBy = append(s.orderBy, strings.Trim(fieldname, " ")+" desc")
-	} else {
-		s.orderBy = append(s.orderBy, strings.Trim(fieldname, " ")+" asc")
+	o := OrderExpr(fieldname)
+	o.descending = descending
+	s.orderBy = append(s.orderBy, o)
+	return s
+}
+
+// Order specifies the ORDER BY clause of sql with extended ordering like NULLS FIRST/LAST, collation
+// or ordering by select-list position.
+// For example
+//
+//	Order(OrderExpr("q.Title").Collate("C"), OrderExpr("q.AddedOn").Desc().NullsLast(), OrderPos(2))
+func (s *selectBuilder) Order(o ...*Ordering) *selectBuilder {
+	s.orderBy = append(s.orderBy, o...)
+	return s
+}
+
+// Distinct removes duplicate rows from resultset.
+func (s *selectBuilder) Distinct() *selectBuilder {
+	s.distinct = true
+	return s
+}
+
+// DistinctOn keeps only first row of each set of rows where given columns are equal.
+// It is supported by PostgreSQL only.
+func (s *selectBuilder) DistinctOn(cols ...string) *selectBuilder {
+	for _, col := range cols {
+		s.distinctOn = append(s.distinctOn, strings.Trim(col, " "))
 	}
 	return s
 }
 
-// Limit limits number of resultant rows.
+// Limit limits number of resultant rows, it is written as top of select on mssql.
 func (s *selectBuilder) Limit(numRows int) *selectBuilder {
 	s.limitRows = numRows
 	return s
@@ -132,13 +306,13 @@ func (s *selectBuilder) NoReadOnly() *selectBuilder {
 
 // Build generates the select SQL along with meta information.
 func (s *selectBuilder) Build(terminateWithSemiColon bool) StatementInfo {
+	s.validateSchema(s)
 	return s.build(terminateWithSemiColon, 0, false)
 }
 
 func (s *selectBuilder) build(terminateWithSemiColon bool, startParam int, issub bool) StatementInfo {
 	var sql strings.Builder
-	s.paramCounter = startParam
-	s.fieldCounter = 0
+	s.reset(startParam)
 
 	cnt := len(s.selectsql)
 	if cnt < 1 {
@@ -146,21 +320,40 @@ func (s *selectBuilder) build(terminateWithSemiColon bool, startParam int, issub
 	}
 
 	sql.WriteString("select ")
+	if len(s.distinctOn) > 0 {
+		if s.dbtype != DbTypePostgreSQL {
+			panic("distinct on is not applicable to mssql/mysql")
+		}
+		sql.WriteString("distinct on (")
+		sql.WriteString(strings.Join(s.distinctOn, ", "))
+		sql.WriteString(") ")
+	} else if s.distinct {
+		sql.WriteString("distinct ")
+	}
+	if s.limitRows > 0 && s.dbtype == DbTypeMsSQL {
+		sql.WriteString("top " + strconv.Itoa(s.limitRows) + " ")
+	}
 	for i, sSQL := range s.selectsql {
 		if i > 0 {
 			sql.Write(comma)
 		}
 
-		if sSQL.subBuilder == nil {
+		if sSQL.caseExpr != nil {
+			s.writeCase(&sql, sSQL.caseExpr, sSQL.fieldname)
+			sql.Write(space)
 			sql.WriteString(sSQL.sql)
+		} else if sSQL.subBuilder == nil {
+			// replace '?' with param i.e $1, $2 ...
+			s.writeParamSQL(&sql, sSQL.sql, exprName(sSQL.sql))
 		} else {
 			sql.Write(openbrace)
 
 			// generate sub-sql
+			sSQL.subBuilder.inheritDialect(&s.builder)
 			subStmp := sSQL.subBuilder.build(false, s.paramCounter, true)
 			// update param, paracount etc as per sub SQL
-			s.paramCounter = subStmp.ParamCount
 			s.addParamToCSV(subStmp.ParamFields)
+			s.paramCounter = subStmp.ParamCount
 
 			sql.WriteString(subStmp.SQL)
 			sql.Write(closebrace)
@@ -174,7 +367,11 @@ func (s *selectBuilder) build(terminateWithSemiColon bool, startParam int, issub
 
 		// do not add fileds to csv for sub-sqls
 		if !issub {
-			s.addFieldToCSV(sSQL.sql)
+			if sSQL.fieldname != "" {
+				s.addFieldToCSV(sSQL.fieldname)
+			} else {
+				s.addFieldToCSV(sSQL.sql)
+			}
 		}
 	}
 
@@ -199,8 +396,8 @@ func (s *selectBuilder) build(terminateWithSemiColon bool, startParam int, issub
 			if x > 0 {
 				sql.Write(comma)
 			}
-			sql.WriteString(s.tables[k])
-			if k != "" {
+			s.writeFrom(&sql, s.tables[k])
+			if k != "" && s.tables[k].proc == nil {
 				sql.Write(space)
 				sql.WriteString(k)
 			}
@@ -208,6 +405,12 @@ func (s *selectBuilder) build(terminateWithSemiColon bool, startParam int, issub
 		}
 	}
 
+	// add lateral joins
+	for _, js := range s.joinsql {
+		sql.Write(space)
+		s.writeJoin(&sql, js)
+	}
+
 	// get where clause
 	if len(s.conditionGroups) > 0 {
 		sql.Write(space)
@@ -215,30 +418,31 @@ func (s *selectBuilder) build(terminateWithSemiColon bool, startParam int, issub
 	}
 
 	// add group by
-	if len(s.groupBy) > 0 {
+	if len(s.groupBy) > 0 || s.grouping != groupingNone {
 		sql.Write(space)
 		sql.WriteString("group by ")
 		for i, str := range s.groupBy {
 			if i > 0 {
 				sql.Write(comma)
 			}
-			sql.WriteString(str)
+			s.writeParamSQL(&sql, str, exprName(str))
 		}
+		s.writeGrouping(&sql)
 	}
 
 	// add order by
 	if len(s.orderBy) > 0 {
 		sql.Write(space)
 		sql.WriteString("order by ")
-		for i, str := range s.orderBy {
+		for i, o := range s.orderBy {
 			if i > 0 {
 				sql.Write(comma)
 			}
-			sql.WriteString(str)
+			s.writeOrdering(&sql, o)
 		}
 	}
 
-	if s.limitRows > 0 {
+	if s.limitRows > 0 && s.dbtype != DbTypeMsSQL {
 		sql.Write(space)
 		sql.WriteString("limit " + strconv.Itoa(s.limitRows))
 	}
@@ -257,6 +461,144 @@ func (s *selectBuilder) build(terminateWithSemiColon bool, startParam int, issub
 	return stmt
 }
 
+// writeGrouping writes ROLLUP, CUBE or GROUPING SETS element of GROUP BY clause
+func (s *selectBuilder) writeGrouping(sql *strings.Builder) {
+	if s.grouping == groupingNone {
+		return
+	}
+
+	if s.dbtype == DbTypeMySQL {
+		switch s.grouping {
+		case groupingCube:
+			panic("group by cube is not applicable to mysql")
+		case groupingSets:
+			panic("grouping sets are not applicable to mysql")
+		}
+		if len(s.groupBy) > 0 {
+			panic("group by rollup cannot be combined with other group by fields on mysql")
+		}
+		sql.WriteString(strings.Join(s.groupingSets[0], ", "))
+		sql.WriteString(" with rollup")
+		return
+	}
+
+	if len(s.groupBy) > 0 {
+		sql.Write(comma)
+	}
+	switch s.grouping {
+	case groupingRollup:
+		sql.WriteString("rollup (")
+		sql.WriteString(strings.Join(s.groupingSets[0], ", "))
+	case groupingCube:
+		sql.WriteString("cube (")
+		sql.WriteString(strings.Join(s.groupingSets[0], ", "))
+	default:
+		sql.WriteString("grouping sets (")
+		for i, set := range s.groupingSets {
+			if i > 0 {
+				sql.Write(comma)
+			}
+			sql.Write(openbrace)
+			sql.WriteString(strings.Join(set, ", "))
+			sql.Write(closebrace)
+		}
+	}
+	sql.Write(closebrace)
+}
+
+// writeFrom writes table name or derived table of FROM clause
+func (s *selectBuilder) writeFrom(sql *strings.Builder, from fromSQL) {
+	if from.proc != nil {
+		// function call writes its own alias
+		s.writeProcSource(sql, from.proc)
+		return
+	}
+	if from.subBuilder == nil {
+		sql.WriteString(from.table)
+		return
+	}
+
+	// generate derived table sql, parameters continue from the select-list
+	from.subBuilder.inheritDialect(&s.builder)
+	subStmp := from.subBuilder.build(false, s.paramCounter, true)
+	s.addParamToCSV(subStmp.ParamFields)
+	s.paramCounter = subStmp.ParamCount
+
+	sql.Write(openbrace)
+	sql.WriteString(subStmp.SQL)
+	sql.Write(closebrace)
+}
+
+// writeProcSource writes call of table function, its parameters continue from builder's param counter
+func (s *selectBuilder) writeProcSource(sql *strings.Builder, proc *procBuilder) {
+	proc.inheritDialect(&s.builder)
+	srcStmt := proc.buildSource(s.paramCounter)
+	s.addParamToCSV(srcStmt.ParamFields)
+	s.paramCounter = srcStmt.ParamCount
+
+	sql.WriteString(srcStmt.SQL)
+}
+
+// writeJoin writes lateral join as per database type
+func (s *selectBuilder) writeJoin(sql *strings.Builder, js joinSQL) {
+	if s.dbtype == DbTypeMsSQL {
+		if js.jointype == joinLeftLateral {
+			sql.WriteString("outer apply ")
+		} else {
+			sql.WriteString("cross apply ")
+		}
+	} else {
+		switch {
+		case js.jointype == joinLeftLateral:
+			sql.WriteString("left join lateral ")
+		case len(js.conditions) > 0:
+			sql.WriteString("join lateral ")
+		default:
+			sql.WriteString("cross join lateral ")
+		}
+	}
+
+	// APPLY does not have ON clause, filter result of sub-sql instead
+	wrap := s.dbtype == DbTypeMsSQL && len(js.conditions) > 0
+	if wrap {
+		sql.WriteString("(select * from ")
+	}
+
+	if js.proc != nil {
+		// function call writes its own alias
+		s.writeProcSource(sql, js.proc)
+	} else {
+		js.subBuilder.inheritDialect(&s.builder)
+		subStmp := js.subBuilder.build(false, s.paramCounter, true)
+		s.addParamToCSV(subStmp.ParamFields)
+		s.paramCounter = subStmp.ParamCount
+
+		sql.Write(openbrace)
+		sql.WriteString(subStmp.SQL)
+		sql.Write(closebrace)
+		sql.Write(space)
+		sql.WriteString(js.alias)
+	}
+
+	if wrap {
+		sql.WriteString(" where (")
+		s.writeConditions(sql, js.conditions, OpAND)
+		sql.WriteString(")) ")
+		sql.WriteString(js.alias)
+	}
+
+	if s.dbtype == DbTypeMsSQL {
+		return
+	}
+	if len(js.conditions) > 0 {
+		sql.WriteString(" on (")
+		s.writeConditions(sql, js.conditions, OpAND)
+		sql.Write(closebrace)
+	} else if js.jointype == joinLeftLateral {
+		sql.WriteString(" on true")
+	}
+}
+
 // BuildWhereClause prepare and return where clause of SQL from builder
 func (s *selectBuilder) BuildWhereClause() string {
 	return s.getWhereClause()
@@ -0,0 +1,764 @@
+package gosql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes statements to generate and files to write them, so statements can be generated without writing
+// a generator program. It is read from JSON by ReadSpec or from YAML by ReadSpecYAML, keys are matched
+// case-insensitively. For example
+//
+//	{
+//	  "package": "sqls", "output": "sqls", "file": "sqlbuilder", "option": "gocode", "dialect": "pgsql",
+//	  "queries": [
+//	    {"group": "user", "key": "create", "description": "Creates new user.",
+//	     "insert": {"table": "users", "columns": ["name", "age"], "returning": ["id"]}},
+//	    {"group": "user", "key": "list", "description": "Lists users by age.",
+//	     "select": {"columns": ["u.id", "u.name"], "from": [{"table": "users", "alias": "u"}],
+//	                "where": [{"field": "u.age", "op": ">", "value": "?"}], "orderBy": [{"expr": "u.name"}]}}
+//	  ]
+//	}
+type Spec struct {
+	// Package is package of generated GO code.
+	Package string
+	// Output is folder to write files to, relative paths are resolved from folder of spec file.
+	Output string
+	// File is name of output files without extension, default is 'sqlbuilder'.
+	File string
+	// Option is one of 'json', 'gocode', 'gocode+json', 'loader' and 'typed', see WriteOption. Default is 'gocode'.
+	Option string
+	// Dialect is database type to build statements for, DATABASE_TYPE is used when it is empty.
+	Dialect string
+	// Dialects writes statements for each of given database types as WriteDialects does, Dialect is ignored then.
+	Dialects []string
+	// Schema lists SQL DDL files or folders of migrations to validate queries against, see LoadDDL.
+	// Relative paths are resolved from folder of spec file.
+	Schema []string
+	// Tables is folder to write GO code with names of tables and columns of Schema to, see Schema.WriteTables.
+	// Package of the code is name of folder, relative path is resolved from folder of spec file.
+	Tables  string
+	Queries []QuerySpec
+}
+
+// QuerySpec describes a statement with its key. Exactly one of Select, Insert, Update, Delete and Proc must be set.
+type QuerySpec struct {
+	Group       string
+	Key         string
+	Description string
+	// Types are GO types of parameters and fields used with option 'typed'.
+	Types  GoTypes
+	Select *SelectSpec
+	Insert *InsertSpec
+	Update *UpdateSpec
+	Delete *DeleteSpec
+	Proc   *ProcSpec
+}
+
+// SelectSpec describes statement of SelectBuilder.
+type SelectSpec struct {
+	Columns     []string
+	Subs        []SubSpec
+	From        []FromSpec
+	Joins       []JoinSpec
+	Where       []ConditionSpec
+	WhereGroups []WhereGroupSpec
+	GroupBy     []string
+	OrderBy     []OrderSpec
+	Distinct    bool
+	DistinctOn  []string
+	Limit       int
+	RowCount    bool
+	NoReadOnly  bool
+}
+
+// SubSpec describes sub-select in select-list, Alias is written after it e.g. 'as total'.
+type SubSpec struct {
+	Select SelectSpec
+	Alias  string
+}
+
+// FromSpec describes table of FROM clause. One of Table, Sub and Proc must be set, Alias is not used with Proc.
+type FromSpec struct {
+	Table string
+	Alias string
+	Sub   *SelectSpec
+	Proc  *ProcSpec
+}
+
+// JoinSpec describes lateral join of sub-select or table function. One of Sub and Proc must be set.
+type JoinSpec struct {
+	Sub   *SelectSpec
+	Proc  *ProcSpec
+	Alias string
+	Left  bool
+	On    []ConditionSpec
+}
+
+// ConditionSpec describes condition of WHERE clause or of join.
+//
+// Op is one of '=', '!=', '>', '>=', '<', '<=' with Value or Sub, 'in' or 'not in' with Sub,
+// 'in' with Values, 'between' with two Values, and 'any' or 'not any' compared with array parameter.
+// Value is a field, a literal or '?' for parameter.
+type ConditionSpec struct {
+	Field   string
+	Op      string
+	Value   string
+	Values  []string
+	Sub     *SelectSpec
+	PgArray bool
+}
+
+// WhereGroupSpec describes grouped conditions added after default WHERE conditions.
+// Op joins the group with preceding conditions and Inner joins conditions within the group, both are 'and' or 'or'.
+// DeleteBuilder supports Op only.
+type WhereGroupSpec struct {
+	Op         string
+	Inner      string
+	Conditions []ConditionSpec
+}
+
+// OrderSpec describes term of ORDER BY clause. One of Expr and Position must be set, Nulls is 'first' or 'last'.
+type OrderSpec struct {
+	Expr     string
+	Position int
+	Desc     bool
+	Nulls    string
+	Collate  string
+}
+
+// InsertSpec describes statement of InsertBuilder.
+type InsertSpec struct {
+	Table     string
+	Columns   []string
+	Returning []string
+}
+
+// UpdateSpec describes statement of UpdateBuilder.
+type UpdateSpec struct {
+	Table       string
+	Columns     []string
+	Calc        []CalcSpec
+	Where       []ConditionSpec
+	WhereGroups []WhereGroupSpec
+	Returning   []string
+}
+
+// CalcSpec describes calculated column of UpdateBuilder e.g. column 'points' with expression 'points+?'.
+type CalcSpec struct {
+	Column string
+	Expr   string
+}
+
+// DeleteSpec describes statement of DeleteBuilder.
+type DeleteSpec struct {
+	Table       string
+	Where       []ConditionSpec
+	WhereGroups []WhereGroupSpec
+	Returning   []string
+}
+
+// ProcSpec describes statement of ProcBuilder.
+// Mode is 'select' (default), 'perform' or 'call'. Alias and ColumnDefs are used when proc is a table source.
+type ProcSpec struct {
+	Name       string
+	Mode       string
+	Columns    []string
+	Args       []ArgSpec
+	NamedArgs  bool
+	Alias      string
+	ColumnDefs []string
+	Where      []ConditionSpec
+}
+
+// ArgSpec describes argument of proc in order of declaration. Direction is 'in' (default), 'out', 'inout' or 'expr'.
+type ArgSpec struct {
+	Name      string
+	Direction string
+}
+
+var specOptions = map[string]WriteOption{
+	"json":        WriteJSON,
+	"gocode":      WriteGoCode,
+	"gocode+json": WriteGoCodeAndJSON,
+	"loader":      WriteJSONandJSONLoaderGoCode,
+	"typed":       WriteTypedGoCode,
+}
+
+// ReadSpec reads Spec from JSON, unknown keys are reported as error.
+func ReadSpec(r io.Reader) (*Spec, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var s Spec
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid spec: %s", err.Error())
+	}
+	return &s, nil
+}
+
+// ReadSpecYAML reads Spec from YAML with same keys as JSON of ReadSpec, unknown keys are reported as error.
+// Values with '?' of parameters must be quoted e.g. 'value: "?"', as '?' has meaning in YAML. For example
+//
+//	package: sqls
+//	dialect: pgsql
+//	queries:
+//	  - group: user
+//	    key: list
+//	    select:
+//	      columns: [u.id, u.name]
+//	      from: [{table: users, alias: u}]
+//	      where: [{field: u.age, op: ">", value: "?"}]
+func ReadSpecYAML(r io.Reader) (*Spec, error) {
+	var doc interface{}
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid spec: %s", err.Error())
+	}
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec: %s", err.Error())
+	}
+	return ReadSpec(strings.NewReader(string(js)))
+}
+
+// LoadSpec reads Spec from file, it is read as YAML when file has extension '.yaml' or '.yml' and as JSON otherwise.
+func LoadSpec(name string) (*Spec, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return ReadSpecYAML(f)
+	default:
+		return ReadSpec(f)
+	}
+}
+
+// WriteOption returns WriteOption of spec.
+func (s *Spec) WriteOption() (WriteOption, error) {
+	if s.Option == "" {
+		return WriteGoCode, nil
+	}
+	option, ok := specOptions[strings.ToLower(s.Option)]
+	if !ok {
+		return 0, fmt.Errorf("unknown option '%s'", s.Option)
+	}
+	return option, nil
+}
+
+// Queue builds all queries of spec and queues them to given FileWriter.
+// Invalid queries are reported together, nothing is queued then.
+func (s *Spec) Queue(fw *FileWriter) error {
+	var errs WriteErrors
+	builders := make([]Builder, len(s.Queries))
+	for i := range s.Queries {
+		q := &s.Queries[i]
+		b, err := q.builder()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("query '%s.%s': %s", q.Group, q.Key, err.Error()))
+			continue
+		}
+		if s.Dialect != "" {
+			b.setDialect(s.Dialect)
+		}
+		builders[i] = b
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for i, q := range s.Queries {
+		fw.QueueBuilderTyped(builders[i], q.Group, q.Key, q.Description, q.Types)
+	}
+	return nil
+}
+
+// Write writes files of spec, dir is folder of spec file which relative Output is resolved from.
+func (s *Spec) Write(dir string) error {
+	fw, option, err := s.fileWriter(dir)
+	if err != nil {
+		return err
+	}
+	if s.Tables != "" {
+		folder := specPath(dir, s.Tables)
+		if err := fw.schema.WriteTables(folder, "tables", filepath.Base(folder)); err != nil {
+			return err
+		}
+	}
+	if len(s.Dialects) > 0 {
+		return fw.WriteDialects(s.outFolder(dir), s.fileName(), s.Package, option, s.Dialects...)
+	}
+	return fw.Write(s.outFolder(dir), s.fileName(), s.Package, option)
+}
+
+// Check is like Write, but returns unified diff of stale files instead of writing them, see FileWriter.Check.
+func (s *Spec) Check(dir string) (string, error) {
+	fw, option, err := s.fileWriter(dir)
+	if err != nil {
+		return "", err
+	}
+	tablesDiff := ""
+	if s.Tables != "" {
+		folder := specPath(dir, s.Tables)
+		if tablesDiff, err = fw.schema.CheckTables(folder, "tables", filepath.Base(folder)); err != nil {
+			return "", err
+		}
+	}
+	var diff string
+	if len(s.Dialects) > 0 {
+		diff, err = fw.CheckDialects(s.outFolder(dir), s.fileName(), s.Package, option, s.Dialects...)
+	} else {
+		diff, err = fw.Check(s.outFolder(dir), s.fileName(), s.Package, option)
+	}
+	return tablesDiff + diff, err
+}
+
+// fileWriter validates spec and returns FileWriter with all queries queued
+func (s *Spec) fileWriter(dir string) (*FileWriter, WriteOption, error) {
+	option, err := s.WriteOption()
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.Dialect != "" && s.Dialect != DbTypePostgreSQL && s.Dialect != DbTypeMsSQL && s.Dialect != DbTypeMySQL {
+		return nil, 0, fmt.Errorf("unsupported database type '%s'", s.Dialect)
+	}
+
+	if s.Tables != "" && len(s.Schema) == 0 {
+		return nil, 0, errors.New("tables require schema")
+	}
+
+	fw := NewFileWriter(len(s.Queries))
+	if len(s.Schema) > 0 {
+		paths := make([]string, len(s.Schema))
+		for i, p := range s.Schema {
+			paths[i] = specPath(dir, p)
+		}
+		schema, err := LoadDDL(paths...)
+		if err != nil {
+			return nil, 0, err
+		}
+		fw.Schema(schema)
+	}
+	if err := s.Queue(fw); err != nil {
+		return nil, 0, err
+	}
+	return fw, option, nil
+}
+
+func (s *Spec) outFolder(dir string) string {
+	return specPath(dir, s.Output)
+}
+
+// specPath resolves path given in spec from folder of spec file
+func specPath(dir, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+func (s *Spec) fileName() string {
+	if s.File == "" {
+		return "sqlbuilder"
+	}
+	return s.File
+}
+
+// builder creates builder of query, misuse of builders is returned as error
+func (q *QuerySpec) builder() (b Builder, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	var builders []Builder
+	if q.Select != nil {
+		sb, err := q.Select.builder()
+		if err != nil {
+			return nil, err
+		}
+		builders = append(builders, sb)
+	}
+	if q.Insert != nil {
+		builders = append(builders, q.Insert.builder())
+	}
+	if q.Update != nil {
+		ub, err := q.Update.builder()
+		if err != nil {
+			return nil, err
+		}
+		builders = append(builders, ub)
+	}
+	if q.Delete != nil {
+		db, err := q.Delete.builder()
+		if err != nil {
+			return nil, err
+		}
+		builders = append(builders, db)
+	}
+	if q.Proc != nil {
+		pb, err := q.Proc.builder()
+		if err != nil {
+			return nil, err
+		}
+		builders = append(builders, pb)
+	}
+
+	if len(builders) != 1 {
+		return nil, errors.New("exactly one of select, insert, update, delete and proc is required")
+	}
+	return builders[0], nil
+}
+
+func (s *SelectSpec) builder() (*selectBuilder, error) {
+	b := SelectBuilder()
+	if len(s.Columns) > 0 {
+		b.Select(s.Columns...)
+	}
+	for _, sub := range s.Subs {
+		sb, err := sub.Select.builder()
+		if err != nil {
+			return nil, err
+		}
+		b.Sub(sb, sub.Alias)
+	}
+
+	for _, from := range s.From {
+		switch {
+		case from.Sub != nil:
+			sb, err := from.Sub.builder()
+			if err != nil {
+				return nil, err
+			}
+			b.FromSub(sb, from.Alias)
+		case from.Proc != nil:
+			pb, err := from.Proc.builder()
+			if err != nil {
+				return nil, err
+			}
+			b.FromProc(pb)
+		default:
+			b.From(from.Table, from.Alias)
+		}
+	}
+
+	for _, join := range s.Joins {
+		on, err := specConditions(join.On)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case join.Sub != nil:
+			sb, err := join.Sub.builder()
+			if err != nil {
+				return nil, err
+			}
+			if join.Left {
+				b.LeftJoinLateral(sb, join.Alias, on...)
+			} else {
+				b.JoinLateral(sb, join.Alias, on...)
+			}
+		case join.Proc != nil:
+			pb, err := join.Proc.builder()
+			if err != nil {
+				return nil, err
+			}
+			if join.Left {
+				b.LeftJoinProc(pb, on...)
+			} else {
+				b.JoinProc(pb, on...)
+			}
+		default:
+			return nil, errors.New("join requires sub or proc")
+		}
+	}
+
+	err := specWhere(s.Where, s.WhereGroups, func(c ...ICondition) { b.Where(c...) },
+		func(outer, inner Operator, c ...ICondition) { b.WhereGroup(outer, inner, c...) })
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.GroupBy) > 0 {
+		b.GroupBy(s.GroupBy...)
+	}
+	for _, o := range s.OrderBy {
+		ordering, err := o.ordering()
+		if err != nil {
+			return nil, err
+		}
+		b.Order(ordering)
+	}
+	if s.Distinct {
+		b.Distinct()
+	}
+	if len(s.DistinctOn) > 0 {
+		b.DistinctOn(s.DistinctOn...)
+	}
+	if s.Limit > 0 {
+		b.Limit(s.Limit)
+	}
+	if s.RowCount {
+		b.RowCount()
+	}
+	if s.NoReadOnly {
+		b.NoReadOnly()
+	}
+	return b, nil
+}
+
+func (s *InsertSpec) builder() *insertBuilder {
+	b := InsertBuilder().Table(s.Table).Columns(s.Columns...)
+	if len(s.Returning) > 0 {
+		b.Returning(s.Returning...)
+	}
+	return b
+}
+
+func (s *UpdateSpec) builder() (*updateBuilder, error) {
+	b := UpdateBuilder().Table(s.Table)
+	if len(s.Columns) > 0 {
+		b.Columns(s.Columns...)
+	}
+	for _, c := range s.Calc {
+		b.CalcColumn(c.Column, c.Expr)
+	}
+
+	err := specWhere(s.Where, s.WhereGroups, func(c ...ICondition) { b.Where(c...) },
+		func(outer, inner Operator, c ...ICondition) { b.WhereGroup(outer, inner, c...) })
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Returning) > 0 {
+		b.Returning(s.Returning...)
+	}
+	return b, nil
+}
+
+func (s *DeleteSpec) builder() (*deleteBuilder, error) {
+	b := DeleteBuilder().Table(s.Table)
+	err := specWhere(s.Where, s.WhereGroups, func(c ...ICondition) { b.Where(c...) },
+		func(outer, inner Operator, c ...ICondition) { b.WhereGroup(outer, c...) })
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Returning) > 0 {
+		b.Returning(s.Returning...)
+	}
+	return b, nil
+}
+
+func (s *ProcSpec) builder() (*procBuilder, error) {
+	b := ProcBuilder()
+	switch strings.ToLower(s.Mode) {
+	case "", "select":
+		b.FromProc(s.Name)
+	case "perform":
+		b.Perform(s.Name)
+	case "call":
+		b.Call(s.Name)
+	default:
+		return nil, fmt.Errorf("unknown proc mode '%s'", s.Mode)
+	}
+	if len(s.Columns) > 0 {
+		b.Select(s.Columns...)
+	}
+
+	for _, arg := range s.Args {
+		switch strings.ToLower(arg.Direction) {
+		case "", "in":
+			b.Param(arg.Name)
+		case "out":
+			b.OutParam(arg.Name)
+		case "inout":
+			b.InOutParam(arg.Name)
+		case "expr":
+			b.ArgExpr(arg.Name)
+		default:
+			return nil, fmt.Errorf("unknown direction '%s' of argument '%s'", arg.Direction, arg.Name)
+		}
+	}
+	if s.NamedArgs {
+		b.NamedArgs()
+	}
+	if s.Alias != "" {
+		b.As(s.Alias, s.ColumnDefs...)
+	}
+
+	if len(s.Where) > 0 {
+		conds, err := specConditions(s.Where)
+		if err != nil {
+			return nil, err
+		}
+		b.Where(conds...)
+	}
+	return b, nil
+}
+
+// specWhere adds default conditions and where groups through given functions of a builder
+func specWhere(where []ConditionSpec, groups []WhereGroupSpec,
+	addWhere func(c ...ICondition), addGroup func(outer, inner Operator, c ...ICondition)) error {
+	if len(where) == 0 {
+		if len(groups) > 0 {
+			return errors.New("where groups require where conditions")
+		}
+		return nil
+	}
+	conds, err := specConditions(where)
+	if err != nil {
+		return err
+	}
+	addWhere(conds...)
+
+	for _, g := range groups {
+		outer, err := specOperator(g.Op)
+		if err != nil {
+			return err
+		}
+		inner := OpAND
+		if g.Inner != "" {
+			if inner, err = specOperator(g.Inner); err != nil {
+				return err
+			}
+		}
+		conds, err := specConditions(g.Conditions)
+		if err != nil {
+			return err
+		}
+		addGroup(outer, inner, conds...)
+	}
+	return nil
+}
+
+func (o *OrderSpec) ordering() (*Ordering, error) {
+	var ordering *Ordering
+	switch {
+	case o.Expr != "" && o.Position == 0:
+		ordering = OrderExpr(o.Expr)
+	case o.Expr == "" && o.Position > 0:
+		ordering = OrderPos(o.Position)
+	default:
+		return nil, errors.New("order requires one of expr and position")
+	}
+
+	if o.Desc {
+		ordering.Desc()
+	}
+	switch strings.ToLower(o.Nulls) {
+	case "":
+	case "first":
+		ordering.NullsFirst()
+	case "last":
+		ordering.NullsLast()
+	default:
+		return nil, fmt.Errorf("unknown nulls order '%s'", o.Nulls)
+	}
+	if o.Collate != "" {
+		ordering.Collate(o.Collate)
+	}
+	return ordering, nil
+}
+
+// specConditions creates conditions of given specs
+func specConditions(specs []ConditionSpec) ([]ICondition, error) {
+	conds := make([]ICondition, 0, len(specs))
+	for _, s := range specs {
+		c, err := s.condition()
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, c)
+	}
+	return conds, nil
+}
+
+func (s *ConditionSpec) condition() (Condition, error) {
+	if s.Field == "" {
+		return Condition{}, errors.New("condition requires field")
+	}
+
+	op := strings.ToLower(strings.Join(strings.Fields(s.Op), " "))
+	if s.Sub != nil {
+		sub, err := s.Sub.builder()
+		if err != nil {
+			return Condition{}, err
+		}
+		switch op {
+		case "=":
+			return C().EQSub(s.Field, sub), nil
+		case "!=", "<>":
+			return C().NEQSub(s.Field, sub), nil
+		case ">":
+			return C().GTSub(s.Field, sub), nil
+		case ">=":
+			return C().GTESub(s.Field, sub), nil
+		case "<":
+			return C().LTSub(s.Field, sub), nil
+		case "<=":
+			return C().LTESub(s.Field, sub), nil
+		case "in":
+			return C().INSub(s.Field, sub), nil
+		case "not in":
+			return C().NINSub(s.Field, sub), nil
+		}
+		return Condition{}, fmt.Errorf("operator '%s' of field '%s' cannot be used with sub", s.Op, s.Field)
+	}
+
+	switch op {
+	case "=", "!=", "<>", ">", ">=", "<", "<=":
+		if s.Value == "" {
+			return Condition{}, fmt.Errorf("operator '%s' of field '%s' requires value or sub", s.Op, s.Field)
+		}
+	}
+
+	switch op {
+	case "=":
+		return C().EQ(s.Field, s.Value), nil
+	case "!=", "<>":
+		return C().NEQ(s.Field, s.Value), nil
+	case ">":
+		return C().GT(s.Field, s.Value), nil
+	case ">=":
+		return C().GTE(s.Field, s.Value), nil
+	case "<":
+		return C().LT(s.Field, s.Value), nil
+	case "<=":
+		return C().LTE(s.Field, s.Value), nil
+	case "between":
+		if len(s.Values) != 2 {
+			return Condition{}, fmt.Errorf("between of field '%s' requires two values", s.Field)
+		}
+		return C().Between(s.Field, s.Values[0], s.Values[1]), nil
+	case "in":
+		if len(s.Values) == 0 {
+			return Condition{}, fmt.Errorf("in of field '%s' requires values or sub", s.Field)
+		}
+		return C().INStr(s.Field, s.Values, s.PgArray), nil
+	case "any":
+		return C().INAnyArray(s.Field, false), nil
+	case "not any":
+		return C().INAnyArray(s.Field, true), nil
+	}
+	return Condition{}, fmt.Errorf("unknown operator '%s' of field '%s'", s.Op, s.Field)
+}
+
+// specOperator returns Operator of 'and' or 'or'
+func specOperator(op string) (Operator, error) {
+	switch strings.ToLower(op) {
+	case "and":
+		return OpAND, nil
+	case "or":
+		return OpOR, nil
+	}
+	return opdefault, fmt.Errorf("unknown operator '%s' of where group, expected 'and' or 'or'", op)
+}
@@ -1,8 +1,10 @@
 package gosql
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -316,6 +318,54 @@ func TestUpdateBuilderPgSQL(t *testing.T) {
 		//fmt.Printf("Sql: %d, Exp: %d\n", len(stmt.SQL), len(exp))
 		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
 	}
+
+	// parameters of calc columns are named by column, as expressions may have commas, and counted on MySQL too
+	os.Setenv("DATABASE_TYPE", DbTypeMySQL)
+
+	stmt = UpdateBuilder().Table("users").
+		Columns("name").
+		CalcColumn("points", "points+?").
+		CalcColumn("rank", "coalesce(rank, ?)").
+		Build(true)
+
+	exp = "update users set name=?, points=points+?, rank=coalesce(rank, ?);"
+	if stmt.SQL != exp || stmt.ParamCount != 3 || stmt.ParamFields != "name, points, rank" {
+		t.Errorf("Expected\n %s %d %s\nGot\n %s %d %s", exp, 3, "name, points, rank", stmt.SQL, stmt.ParamCount, stmt.ParamFields)
+	}
+}
+
+func TestUpdateCalcColumnsOrder(t *testing.T) {
+	fmt.Println("\n\nTestUpdateCalcColumnsOrder ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	u := UpdateBuilder().Table("users").
+		Columns("a").
+		CalcColumn("b", "b+?").
+		CalcColumn("c", "c+?").
+		CalcColumn("d", "d+?").
+		CalcColumn("b", "b-?")
+
+	exp := "update users set a=$1, b=b-$2, c=c+$3, d=d+$4;"
+	for i := 0; i < 20; i++ {
+		stmt := u.Build(true)
+		if stmt.SQL != exp || stmt.ParamFields != "a, b, c, d" {
+			t.Fatalf("Expected\n %s %s\nGot\n %s %s", exp, "a, b, c, d", stmt.SQL, stmt.ParamFields)
+		}
+	}
+
+	// order of calc columns is kept by JSON
+	data, err := json.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := UnmarshalBuilder(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Build(true).SQL; got != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, got)
+	}
 }
 
 func TestDeleteBuilder(t *testing.T) {
@@ -357,7 +407,7 @@ func TestProcBuilder(t *testing.T) {
 		RowCount().
 		Build(true)
 
-	exp = "perform proc1($1, $2);"
+	exp = "select proc1($1, $2);"
 	if stmt.SQL != exp {
 		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
 	}
@@ -473,3 +523,941 @@ func TestWhereGroupClausePgSQL(t *testing.T) {
 		t.Errorf("Expected\n %s\nGot\n %s", exp, sql)
 	}
 }
+
+func TestFromSubPgSQL(t *testing.T) {
+	fmt.Println("\n\nTestFromSub ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	stmt := SelectBuilder().Select("t.ID", "t.Total").
+		FromSub(SelectBuilder().Select("QID as ID", "count(*) as Total").
+			From("Answers", "").
+			Where(C().GT("AddedOn", "?")).
+			GroupBy("QID"), "t").
+		Where(C().GT("t.Total", "?")).
+		Build(true)
+
+	if stmt.ParamCount != 2 {
+		t.Errorf("Expected Paramters\n %d\nGot\n %d", 2, stmt.ParamCount)
+	}
+
+	exp := "select t.ID, t.Total from (select QID as ID, count(*) as Total from answers where (AddedOn>$1) group by QID) t where (t.Total>$2);"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+}
+
+func TestJoinLateralPgSQL(t *testing.T) {
+	fmt.Println("\n\nTestJoinLateral ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	stmt := SelectBuilder().Select("t.ID", "q.ID").
+		From("Topics", "t").
+		JoinLateral(SelectBuilder().Select("ID").
+			From("Questions", "").
+			Where(C().EQ("TopicID", "t.ID"), C().GT("Marks", "?")).
+			OrderBy("AddedOn", true).
+			Limit(3), "q").
+		Where(C().EQ("t.SubjectID", "?")).
+		Build(true)
+
+	if stmt.ParamCount != 2 || stmt.ParamFields != "Marks, t.SubjectID" {
+		t.Errorf("Expected Paramters\n %d %s\nGot\n %d %s", 2, "Marks, t.SubjectID", stmt.ParamCount, stmt.ParamFields)
+	}
+
+	exp := "select t.ID, q.ID from topics t cross join lateral (select ID from questions where (Marks>$1 and TopicID=t.ID) order by AddedOn desc limit 3) q where (t.SubjectID=$2);"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+
+	stmt = SelectBuilder().Select("t.ID", "q.ID").
+		From("Topics", "t").
+		LeftJoinLateral(SelectBuilder().Select("ID", "TopicID").
+			From("Questions", "").
+			Limit(1), "q", C().EQ("q.TopicID", "t.ID")).
+		Build(false)
+
+	exp = "select t.ID, q.ID from topics t left join lateral (select ID, TopicID from questions limit 1) q on (q.TopicID=t.ID)"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+}
+
+func TestJoinLateralMsSQL(t *testing.T) {
+	fmt.Println("\n\nTestJoinLateral ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypeMsSQL)
+
+	stmt := SelectBuilder().Select("t.ID", "q.ID").
+		From("Topics", "t").
+		JoinLateral(SelectBuilder().Select("ID").
+			From("Questions", "").
+			Where(C().EQ("TopicID", "t.ID")).
+			OrderBy("AddedOn", true).
+			Limit(3), "q").
+		LeftJoinLateral(SelectBuilder().Select("QID", "Title").
+			From("QuestionData", ""), "qd", C().EQ("qd.QID", "q.ID")).
+		Where(C().EQ("t.SubjectID", "?")).
+		Build(true)
+
+	exp := "select t.ID, q.ID from topics t cross apply (select top 3 ID from questions where (TopicID=t.ID) order by AddedOn desc) q " +
+		"outer apply (select * from (select QID, Title from questiondata) qd where (qd.QID=q.ID)) qd where (t.SubjectID=@p1);"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+}
+
+func TestDistinctAndOrdering(t *testing.T) {
+	fmt.Println("\n\nTestDistinctAndOrdering ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	stmt := SelectBuilder().Select("q.TopicID", "q.ID", "q.Title").
+		From("Questions", "q").
+		DistinctOn("q.TopicID").
+		Order(OrderExpr("q.TopicID"), OrderExpr("q.AddedOn").Desc().NullsLast(), OrderExpr("q.Title").Collate(`"C"`), OrderPos(2).NullsFirst()).
+		Build(true)
+
+	exp := `select distinct on (q.TopicID) q.TopicID, q.ID, q.Title from questions q order by q.TopicID asc, q.AddedOn desc nulls last, q.Title collate "C" asc, 2 asc nulls first;`
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+
+	os.Setenv("DATABASE_TYPE", DbTypeMsSQL)
+
+	stmt = SelectBuilder().Select("q.TopicID").
+		From("Questions", "q").
+		Distinct().
+		Order(OrderExpr("q.AddedOn").Desc().NullsFirst(), OrderExpr("q.Marks").NullsFirst(), OrderExpr("q.Title").NullsLast().Collate("Latin1_General_CI_AS")).
+		Limit(5).
+		Build(true)
+
+	exp = "select distinct top 5 q.TopicID from questions q order by case when q.AddedOn is null then 0 else 1 end, q.AddedOn desc, " +
+		"q.Marks asc, case when q.Title is null then 1 else 0 end, q.Title collate Latin1_General_CI_AS asc;"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic for distinct on with mssql")
+		}
+	}()
+	SelectBuilder().Select("q.ID").From("Questions", "q").DistinctOn("q.ID").Build(true)
+}
+
+func TestGroupingSets(t *testing.T) {
+	fmt.Println("\n\nTestGroupingSets ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	stmt := SelectBuilder().Select("Year", "Month", "sum(Amount) as Total").
+		Grouping("Month", "IsYearTotal").
+		From("Invoices", "").
+		GroupByRollup("Year", "Month").
+		Build(true)
+
+	exp := "select Year, Month, sum(Amount) as Total, grouping(Month) as IsYearTotal from invoices group by rollup (Year, Month);"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+	if stmt.Fields != "Year, Month, sum(Amount) as Total, IsYearTotal" {
+		t.Errorf("Expected Fields\n %s\nGot\n %s", "Year, Month, sum(Amount) as Total, IsYearTotal", stmt.Fields)
+	}
+
+	os.Setenv("DATABASE_TYPE", DbTypeMsSQL)
+
+	stmt = SelectBuilder().Select("Region", "Year", "sum(Amount) as Total").
+		From("Invoices", "").
+		GroupBy("Region").
+		GroupingSets([]string{"Year"}, []string{}).
+		Build(true)
+
+	exp = "select Region, Year, sum(Amount) as Total from invoices group by Region, grouping sets ((Year), ());"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+
+	stmt = SelectBuilder().Select("Region", "Year", "sum(Amount) as Total").
+		From("Invoices", "").
+		GroupByCube("Region", "Year").
+		Build(true)
+
+	exp = "select Region, Year, sum(Amount) as Total from invoices group by cube (Region, Year);"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+
+	os.Setenv("DATABASE_TYPE", DbTypeMySQL)
+
+	stmt = SelectBuilder().Select("Year", "Month", "sum(Amount) as Total").
+		From("Invoices", "").
+		GroupByRollup("Year", "Month").
+		Build(true)
+
+	exp = "select Year, Month, sum(Amount) as Total from invoices group by Year, Month with rollup;"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic for cube with mysql")
+		}
+	}()
+	SelectBuilder().Select("Year").From("Sales", "").GroupByCube("Year").Build(true)
+}
+
+func TestCaseExpr(t *testing.T) {
+	fmt.Println("\n\nTestCaseExpr ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	stmt := SelectBuilder().Select("q.ID").
+		SelectCase(Case().When(C().GT("q.Marks", "?"), "'pass'").Else("'fail'"), "Result").
+		From("Questions", "q").
+		Where(C().EQ("q.TopicID", "?")).
+		Order(OrderCase(Case().When(C().EQ("q.Level", "?"), "0").Else("1")), OrderExpr("q.ID")).
+		Build(true)
+
+	exp := "select q.ID, case when q.Marks>$1 then 'pass' else 'fail' end as Result from questions q where (q.TopicID=$2) " +
+		"order by case when q.Level=$3 then 0 else 1 end asc, q.ID asc;"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+	if stmt.ParamCount != 3 || stmt.ParamFields != "q.Marks, q.TopicID, q.Level" {
+		t.Errorf("Expected Paramters\n %d %s\nGot\n %d %s", 3, "q.Marks, q.TopicID, q.Level", stmt.ParamCount, stmt.ParamFields)
+	}
+	if stmt.Fields != "q.ID, Result" {
+		t.Errorf("Expected Fields\n %s\nGot\n %s", "q.ID, Result", stmt.Fields)
+	}
+
+	os.Setenv("DATABASE_TYPE", DbTypeMsSQL)
+
+	stmt = UpdateBuilder().Table("users").
+		Columns("name").
+		CaseColumn("status", Case().When(C().GT("points", "?"), "?").Else("status")).
+		Where(C().EQ("id", "?")).
+		Build(true)
+
+	exp = "update users set name=@p1, status=case when points>@p2 then @p3 else status end where (id=@p4);"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+	if stmt.ParamCount != 4 {
+		t.Errorf("Expected Paramters\n %d\nGot\n %d", 4, stmt.ParamCount)
+	}
+}
+
+func TestSelectListParams(t *testing.T) {
+	fmt.Println("\n\nTestSelectListParams ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	stmt := SelectBuilder().Select("q.ID", "coalesce(q.Marks, ?) as Marks").
+		Sub(SelectBuilder().Select("count(*)").From("Answers", "a").
+			Where(C().EQ("a.QID", "q.ID"), C().GT("a.AddedOn", "?")), "as Answers").
+		Select("q.Level*? as Weight").
+		From("Questions", "q").
+		Where(C().EQ("q.TopicID", "?")).
+		GroupBy("q.ID", "q.Marks", "q.Level*?").
+		OrderBy("q.Level*?", true).
+		Build(true)
+
+	exp := "select q.ID, coalesce(q.Marks, $1) as Marks, (select count(*) from answers a where (a.AddedOn>$2 and a.QID=q.ID)) as Answers, q.Level*$3 as Weight " +
+		"from questions q where (q.TopicID=$4) group by q.ID, q.Marks, q.Level*$5 order by q.Level*$6 desc;"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+
+	expParams := "Marks, a.AddedOn, Weight, q.TopicID, q.Level, q.Level"
+	if stmt.ParamCount != 6 || stmt.ParamFields != expParams {
+		t.Errorf("Expected Paramters\n %d %s\nGot\n %d %s", 6, expParams, stmt.ParamCount, stmt.ParamFields)
+	}
+
+	os.Setenv("DATABASE_TYPE", DbTypeMsSQL)
+
+	stmt = SelectBuilder().Select("coalesce(q.Marks, ?) as Marks").
+		From("Questions", "q").
+		Where(C().EQ("q.TopicID", "?")).
+		Build(false)
+
+	exp = "select coalesce(q.Marks, @p1) as Marks from questions q where (q.TopicID=@p2)"
+	if stmt.SQL != exp || stmt.ParamCount != 2 {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+
+	// parameters of expressions with commas are named by their column, so ParamFields splits into one name per parameter
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	stmt = SelectBuilder().Select("coalesce(q.Level, ?) as lvl").
+		From("Questions", "q").
+		Where(C().EQ("q.TopicID", "?")).
+		GroupBy("coalesce(q.Level, ?)").
+		OrderBy("coalesce(q.Level, ?)", false).
+		Build(true)
+
+	expParams = "lvl, q.TopicID, q.Level, q.Level"
+	if stmt.ParamCount != 4 || stmt.ParamFields != expParams || len(splitCSV(stmt.ParamFields)) != 4 {
+		t.Errorf("Expected Paramters\n %d %s\nGot\n %d %s", 4, expParams, stmt.ParamCount, stmt.ParamFields)
+	}
+
+	// aliases within parenthesis and parameters of functions do not name parameters, their position does
+	stmt = SelectBuilder().Select("cast(? as int)", "q.Level*? as weight").
+		From("Questions", "q").
+		GroupBy("date_trunc(?, q.AddedOn)").
+		Build(true)
+
+	expParams = "?, weight, ?"
+	if stmt.ParamCount != 3 || stmt.ParamFields != expParams || len(splitCSV(stmt.ParamFields)) != 3 {
+		t.Errorf("Expected Paramters\n %d %s\nGot\n %d %s", 3, expParams, stmt.ParamCount, stmt.ParamFields)
+	}
+
+	// '?' within quotes is not a parameter, '??' is literal '?' and subscripts take parameters
+	stmt = SelectBuilder().Select("'why?' as q", `"a?" as b`, "data ?? 'k' as has", "tags[?] as tag").
+		From("Questions", "q").
+		Where(C().EQ("q.Title", "'what?'"), C().EQ("q.Note", "'it''s?'"), C().EQ("q.TopicID", "?")).
+		Build(true)
+
+	exp = `select 'why?' as q, "a?" as b, data ? 'k' as has, tags[$1] as tag from questions q ` +
+		`where (q.Note='it''s?' and q.Title='what?' and q.TopicID=$2);`
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+	expParams = "tag, q.TopicID"
+	if stmt.ParamCount != 2 || stmt.ParamFields != expParams {
+		t.Errorf("Expected Paramters\n %d %s\nGot\n %d %s", 2, expParams, stmt.ParamCount, stmt.ParamFields)
+	}
+
+	os.Setenv("DATABASE_TYPE", DbTypeMsSQL)
+
+	stmt = SelectBuilder().Select("[why?] as w", "`a?` as b").
+		From("Questions", "q").
+		Where(C().EQ("q.Title", "'what?'")).
+		Build(false)
+
+	exp = "select [why?] as w, `a?` as b from questions q where (q.Title='what?')"
+	if stmt.SQL != exp || stmt.ParamCount != 0 || stmt.ParamFields != "" {
+		t.Errorf("Expected\n %s\nGot\n %s %d %s", exp, stmt.SQL, stmt.ParamCount, stmt.ParamFields)
+	}
+}
+
+func TestProcBuilderOutParams(t *testing.T) {
+	fmt.Println("\n\nTestProcBuilderOutParams ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypeMySQL)
+
+	stmt := ProcBuilder().Perform("adduser").
+		Param("email", "name").
+		OutParam("userid").
+		Build(true)
+
+	exp := "call adduser(?, ?, @userid);"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+	if stmt.ParamCount != 2 || stmt.ParamFields != "email, name" || stmt.OutParamFields != "userid" {
+		t.Errorf("Expected Paramters\n %d %s %s\nGot\n %d %s %s", 2, "email, name", "userid", stmt.ParamCount, stmt.ParamFields, stmt.OutParamFields)
+	}
+
+	os.Setenv("DATABASE_TYPE", DbTypeMsSQL)
+
+	stmt = ProcBuilder().Perform("adduser").
+		NamedArgs().
+		Param("email").
+		OutParam("userid").
+		InOutParam("credits").
+		Build(true)
+
+	exp = "exec adduser @email=@p1, @userid=@p2 output, @credits=@p3 output;"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+	if stmt.ParamCount != 3 || stmt.OutParamFields != "userid, credits" {
+		t.Errorf("Expected Paramters\n %d %s\nGot\n %d %s", 3, "userid, credits", stmt.ParamCount, stmt.OutParamFields)
+	}
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	stmt = ProcBuilder().Select("userid").
+		FromProc("adduser").
+		NamedArgs().
+		Param("email").
+		OutParam("userid").
+		Build(true)
+
+	exp = "select userid from adduser(email => $1);"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+}
+
+func TestProcBuilderWhere(t *testing.T) {
+	fmt.Println("\n\nTestProcBuilderWhere ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	stmt := ProcBuilder().Select("u.id", "u.name").
+		FromProc("getusers").
+		Param("role").
+		As("u", "id integer", "name text", "active boolean").
+		Where(C().EQ("u.active", "true"), C().GT("u.id", "?")).
+		OrderBy("u.name", false).
+		Limit(10).
+		Build(true)
+
+	exp := "select u.id, u.name from getusers($1) as u(id integer, name text, active boolean) where (u.active=true and u.id>$2) order by u.name asc limit 10;"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+	if stmt.ParamCount != 2 || stmt.ParamFields != "role, u.id" {
+		t.Errorf("Expected Paramters\n %d %s\nGot\n %d %s", 2, "role, u.id", stmt.ParamCount, stmt.ParamFields)
+	}
+
+	stmt = SelectBuilder().Select("t.ID", "q.ID").
+		From("Topics", "t").
+		JoinProc(ProcBuilder().FromProc("topquestions").ArgExpr("t.ID").Param("limit").As("q")).
+		FromProc(ProcBuilder().FromProc("activeusers").Param("since").As("u")).
+		Where(C().EQ("q.AddedBy", "u.ID"), C().GT("q.Marks", "?")).
+		Build(true)
+
+	exp = "select t.ID, q.ID from topics t, activeusers($1) as u cross join lateral topquestions(t.ID, $2) as q where (q.AddedBy=u.ID and q.Marks>$3);"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+	if stmt.ParamFields != "since, limit, q.Marks" {
+		t.Errorf("Expected ParamFields\n %s\nGot\n %s", "since, limit, q.Marks", stmt.ParamFields)
+	}
+
+	os.Setenv("DATABASE_TYPE", DbTypeMsSQL)
+
+	stmt = SelectBuilder().Select("t.ID", "q.ID").
+		From("Topics", "t").
+		LeftJoinProc(ProcBuilder().FromProc("dbo.topquestions").Param("limit").As("q"), C().EQ("q.TopicID", "t.ID")).
+		Build(true)
+
+	exp = "select t.ID, q.ID from topics t outer apply (select * from dbo.topquestions(@p1) as q where (q.TopicID=t.ID)) q;"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+}
+
+func TestProcBuilderCall(t *testing.T) {
+	fmt.Println("\n\nTestProcBuilderCall ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	stmt := ProcBuilder().Call("transfer").
+		Param("fromacc", "toacc").
+		InOutParam("amount").
+		OutParam("status").
+		Build(true)
+
+	exp := "call transfer($1, $2, $3, null);"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+	if stmt.ParamCount != 3 || stmt.OutParamFields != "amount, status" {
+		t.Errorf("Expected Paramters\n %d %s\nGot\n %d %s", 3, "amount, status", stmt.ParamCount, stmt.OutParamFields)
+	}
+	if stmt.ReadOnly {
+		t.Errorf("Expected call to be not ReadOnly")
+	}
+
+	os.Setenv("DATABASE_TYPE", DbTypeMySQL)
+
+	stmt = ProcBuilder().Call("transfer").
+		Param("fromacc", "toacc").
+		InOutParam("amount").
+		Build(true)
+
+	exp = "call transfer(?, ?, @amount);"
+	if stmt.SQL != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+	}
+}
+
+func TestBuilderJSON(t *testing.T) {
+	fmt.Println("\n\nTestBuilderJSON ***")
+
+	builders := []Builder{
+		SelectBuilder().Select("t.ID", "q.ID").
+			Sub(SelectBuilder().Select("count(*)").From("Answers", "a").Where(C().EQ("a.QID", "q.ID")), "as Answers").
+			SelectCase(Case().When(C().GT("q.Marks", "?"), "'pass'").Else("'fail'"), "Result").
+			From("Topics", "t").
+			LeftJoinLateral(SelectBuilder().Select("ID", "Marks").
+				From("Questions", "").
+				Where(C().EQ("TopicID", "t.ID"), C().GT("Marks", "?")).
+				Limit(3), "q", C().EQ("q.TopicID", "t.ID")).
+			Where(C().EQ("t.SubjectID", "?")).
+			WhereGroup(OpOR, OpAND, C().INInt("t.Level", []int{1, 2}, false), C().INSub("t.ID", SelectBuilder().Select("TopicID").From("Featured", "f"))).
+			GroupByRollup("t.ID", "q.ID").
+			Order(OrderCase(Case().When(C().EQ("q.Level", "?"), "0").Else("1")), OrderExpr("q.ID").Desc().NullsLast()).
+			Limit(10).
+			RowCount(),
+		SelectBuilder().Select("u.ID", "r.Name").
+			FromProc(ProcBuilder().FromProc("userroles").Param("userid").As("r", "ID int", "Name text")).
+			From("Users", "u").
+			Where(C().EQ("u.ID", "r.ID")).
+			NoReadOnly(),
+		InsertBuilder().Table("users").Columns("name", "email").Returning("id"),
+		UpdateBuilder().Table("users").
+			Columns("name").
+			CalcColumn("points", "points+?").
+			CaseColumn("status", Case().When(C().GT("points", "?"), "?").Else("status")).
+			Where(C().EQ("id", "?")),
+		DeleteBuilder().Table("users").Where(C().EQ("id", "?")).WhereGroup(OpOR, C().EQ("status", "?")),
+		SelectBuilder().Select("ID").From("Users", "u").
+			Where(C().Between("u.Age", "?", "?"), C().INStr("u.Role", []string{"admin"}, false), C().INFloat("u.Score", []float64{1.5}, true),
+				C().INAnyArray("u.ID", true), C().NINSub("u.ID", SelectBuilder().Select("UserID").From("Banned", "b")), C().LTE("u.Level", "3")),
+		ProcBuilder().Call("transfer").Param("fromacc", "toacc").InOutParam("amount").OutParam("status"),
+		// where clause on proc is PostgreSQL only
+		ProcBuilder().Select("ID", "Name").FromProc("getusers").Param("role").
+			Where(C().GT("ID", "?")).OrderBy("Name", false).Limit(5),
+	}
+	pgOnly := len(builders) - 1
+
+	for i, b := range builders {
+		data, err := MarshalBuilder(b)
+		if err != nil {
+			t.Fatalf("Builder %d: %s", i, err)
+		}
+		nb, err := UnmarshalBuilder(data)
+		if err != nil {
+			t.Fatalf("Builder %d: %s\n%s", i, err, data)
+		}
+		ndata, _ := MarshalBuilder(nb)
+		if string(ndata) != string(data) {
+			t.Errorf("Expected\n %s\nGot\n %s", data, ndata)
+		}
+		// json.Marshal gives same JSON, with HTML characters escaped
+		if jdata, _ := json.Marshal(b); !json.Valid(jdata) || len(jdata) < len(data) {
+			t.Errorf("Expected\n %s\nGot\n %s", data, jdata)
+		}
+
+		for _, d := range []string{DbTypePostgreSQL, DbTypeMsSQL} {
+			if i == pgOnly && d != DbTypePostgreSQL {
+				continue
+			}
+			b.setDialect(d)
+			nb.setDialect(d)
+			exp, got := b.Build(true), nb.Build(true)
+			if got != exp {
+				t.Errorf("Expected\n %+v\nGot\n %+v", exp, got)
+			}
+		}
+	}
+
+	// conditions are data, which can be read and edited without parsing SQL
+	data, _ := MarshalBuilder(SelectBuilder().Select("ID").From("Questions", "q").
+		Where(C().GT("q.Marks", "?"), C().INInt("q.Level", []int{1, 2}, false)))
+	exp := `"Where":[{"Conditions":[{"Field":"q.Marks","Op":">","Value":"?"},{"Field":"q.Level","Op":"in","Ints":[1,2]}]}]`
+	if !strings.Contains(string(data), exp) {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, data)
+	}
+	data = []byte(strings.Replace(string(data), `"Op":">"`, `"Op":"<="`, 1))
+	b, err := UnmarshalBuilder(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.setDialect(DbTypePostgreSQL)
+	expSQL := "select ID from questions q where (q.Level IN (1,2) and q.Marks<=$1);"
+	if got := b.Build(true).SQL; got != expSQL {
+		t.Errorf("Expected\n %s\nGot\n %s", expSQL, got)
+	}
+
+	errs := map[string]string{
+		`{"Type":"delete","Where":[{"Conditions":[{"Field":"id","Op":"~"}]}]}`:                   "unknown operator '~' of field 'id'",
+		`{"Type":"delete","Where":[{"Conditions":[{"Field":"id","Op":"="}]}]}`:                   "operator '=' of field 'id' requires value or sub",
+		`{"Type":"delete","Where":[{"Conditions":[{"Field":"id","Op":"in"}]}]}`:                  "in of field 'id' requires one of values, ints, floats and sub",
+		`{"Type":"delete","Where":[{"Conditions":[{"Op":"=","Value":"1"}]}]}`:                    "condition requires field",
+		`{"Type":"delete","Where":[{"Conditions":[{"Field":"id","Op":"between","Value":"1"}]}]}`: "between of field 'id' requires two values",
+	}
+	for data, e := range errs {
+		if _, err := UnmarshalBuilder([]byte(data)); err == nil || err.Error() != e {
+			t.Errorf("Expected\n %s\nGot\n %v", e, err)
+		}
+	}
+	if _, err := MarshalBuilder(DropTableBuilder().Table("users")); err == nil {
+		t.Errorf("Expected error for builder without JSON")
+	}
+
+	if _, err := UnmarshalBuilder([]byte(`{"Type":"merge"}`)); err == nil {
+		t.Errorf("Expected error for unknown builder type")
+	}
+	if _, err := UnmarshalBuilder([]byte(`{"Type":"select","Where":[{"Conditions":[]},{"Conditions":[]}]}`)); err == nil {
+		t.Errorf("Expected error for where group without operator")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	fmt.Println("\n\nTestSchema ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	db := NewSchema()
+	db.AddTable("Topics",
+		Column{Name: "ID", Type: "serial", PrimaryKey: true},
+		Column{Name: "Title", Type: "varchar(100)", NotNull: true})
+	db.AddTable("Questions",
+		Column{Name: "ID", Type: "serial", PrimaryKey: true},
+		Column{Name: "TopicID", Type: "int", NotNull: true},
+		Column{Name: "Title", Type: "text", NotNull: true},
+		Column{Name: "Marks", Type: "numeric(5,2)"},
+		Column{Name: "AddedOn", Type: "timestamp", NotNull: true, Default: "now()"},
+		Column{Name: "Score", Type: "numeric", NotNull: true, Computed: true})
+
+	// valid statements build as usual
+	stmt := SelectBuilder().Schema(db).Select("q.ID", "q.Title", "count(*) as Cnt").
+		From("Questions", "q").
+		From("Topics", "t").
+		LeftJoinLateral(SelectBuilder().Select("ID").From("Questions", "").Where(C().EQ("TopicID", "t.ID")).Limit(1), "l").
+		Where(C().EQ("q.TopicID", "t.ID"), C().GT("q.Marks", "?"), C().EQ("q.Title", "'maths'")).
+		WhereGroup(OpOR, OpAND, C().INSub("q.TopicID", SelectBuilder().Select("ID").From("Topics", "").Where(C().EQ("Title", "?")))).
+		GroupBy("q.ID", "q.Title").
+		OrderBy("Cnt", true).
+		Build(true)
+	if stmt.ParamCount != 2 {
+		t.Errorf("Expected Paramters\n %d\nGot\n %d", 2, stmt.ParamCount)
+	}
+
+	err := db.Validate(InsertBuilder().Table("questions").Columns("TopicID", "Title").Returning("ID"))
+	if err != nil {
+		t.Errorf("Expected no error\nGot\n %s", err)
+	}
+
+	tests := []struct {
+		b   Builder
+		exp string
+	}{
+		{SelectBuilder().Select("q.ID", "q.Titel", "Markz").From("Questions", "q").Where(C().EQ("x.ID", "?")),
+			"unknown column 'Titel' of table 'Questions'; unknown column 'Markz'; unknown alias 'x' in 'x.ID'"},
+		{SelectBuilder().Select("ID").From("Question", ""),
+			"unknown table 'question'"},
+		{SelectBuilder().Select("q.ID").From("Questions", "q").From("Topics", "t").
+			Where(C().EQ("q.TopicID", "t.Title"), C().EQ("q.Marks", "'high'"), C().EQ("q.Title", "5")),
+			"type mismatch in 'q.TopicID=t.Title': int compared with varchar(100); " +
+				"type mismatch in 'q.Marks='high'': numeric(5,2) compared with 'high'; " +
+				"type mismatch in 'q.Title=5': text compared with 5"},
+		{InsertBuilder().Table("Questions").Columns("Title", "Markss"),
+			"unknown column 'Markss' of table 'Questions'; missing required column 'TopicID' of table 'Questions'"},
+		{UpdateBuilder().Table("Questions").Columns("Title").CalcColumn("Marks", "Marks+?").Where(C().EQ("Topic", "?")),
+			"unknown column 'Topic'"},
+		{InsertBuilder().Table("Questions").Columns("TopicID", "Title", "Score"),
+			"cannot write computed column 'Score' of table 'Questions'"},
+		{UpdateBuilder().Table("Questions").CalcColumn("Score", "Marks*2").Where(C().EQ("ID", "?")),
+			"cannot write computed column 'Score' of table 'Questions'"},
+		{DeleteBuilder().Table("Topics").Where(C().INInt("Title", []int{1, 2}, false)).Returning("Name"),
+			"unknown column 'Name' of table 'Topics'; type mismatch in 'Title IN (1,2)': varchar(100) compared with 1"},
+	}
+	for _, test := range tests {
+		err := db.Validate(test.b)
+		if err == nil || err.Error() != test.exp {
+			t.Errorf("Expected\n %s\nGot\n %v", test.exp, err)
+		}
+	}
+
+	defer func() {
+		r := recover()
+		if _, ok := r.(SchemaErrors); !ok {
+			t.Errorf("Expected panic with SchemaErrors\nGot\n %v", r)
+		}
+	}()
+	InsertBuilder().Schema(db).Table("Topics").Columns("Name").Build(true)
+}
+
+func TestParseDDL(t *testing.T) {
+	fmt.Println("\n\nTestParseDDL ***")
+
+	ddl := `
+-- PostgreSQL
+CREATE TABLE IF NOT EXISTS public.topics (
+	id serial PRIMARY KEY,
+	title varchar(100) NOT NULL,
+	tags text[] DEFAULT '{}'::text[]
+);
+CREATE TABLE questions (
+	id bigint GENERATED ALWAYS AS IDENTITY,
+	topic_id int NOT NULL REFERENCES topics(id) ON DELETE CASCADE,
+	marks numeric(5,2) CHECK (marks > 0),
+	"Title" text NOT NULL,
+	score numeric GENERATED ALWAYS AS (marks * 2) STORED,
+	CONSTRAINT pk_questions PRIMARY KEY (id)
+);
+DROP TABLE IF EXISTS zzz;
+ALTER TABLE IF EXISTS zzz ADD COLUMN a int;
+ALTER TABLE questions ADD COLUMN level int NOT NULL DEFAULT 1,
+	ALTER COLUMN marks TYPE numeric(6,2) USING marks::numeric,
+	RENAME COLUMN "Title" TO title;
+CREATE FUNCTION topic_count() RETURNS TABLE(n int) AS $$ select count(*) from topics; $$ LANGUAGE sql;
+CREATE VIEW topic_questions AS
+	SELECT q.id, q.title AS name, t.title topic, count(*) cnt, q.marks::text marks
+	FROM questions q JOIN topics t ON t.id = q.topic_id;
+
+# MySQL
+CREATE TABLE ` + "`users`" + ` (
+	` + "`id`" + ` int unsigned NOT NULL AUTO_INCREMENT,
+	` + "`email`" + ` varchar(255) CHARACTER SET utf8mb4 NOT NULL COMMENT 'login',
+	` + "`updated`" + ` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (` + "`id`" + `),
+	KEY idx_email (email)
+) ENGINE=InnoDB;
+ALTER TABLE users MODIFY email varchar(320) NOT NULL, CHANGE updated modified datetime;
+RENAME TABLE users TO accounts;
+GO
+/* MS-SQL */
+CREATE TABLE [dbo].[Orders] (
+	[ID] int IDENTITY(1,1) NOT NULL,
+	[Amount] decimal(10,2) NOT NULL CONSTRAINT DF_Amount DEFAULT (0),
+	[Total] AS ([Amount] * 2),
+	CONSTRAINT [PK_Orders] PRIMARY KEY CLUSTERED ([ID] ASC)
+)
+GO
+ALTER TABLE dbo.Orders ADD Note nvarchar(max) NULL, Status bit NOT NULL
+GO
+ALTER TABLE dbo.Orders ADD CONSTRAINT DF_Status DEFAULT 0 FOR Status
+GO
+EXEC sp_rename 'dbo.Orders.Note', 'Remark', 'COLUMN'
+GO
+IF OBJECT_ID('dbo.Temp') IS NULL CREATE TABLE dbo.Temp (x int)
+GO
+DROP TABLE IF EXISTS dbo.Temp
+`
+	s, err := ParseDDL(strings.NewReader(ddl))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got strings.Builder
+	for _, tbl := range s.Tables() {
+		got.WriteString(fmt.Sprintf("%s %v %v\n", tbl.Name, tbl.View, tbl.PrimaryKey))
+		for _, c := range tbl.Columns {
+			got.WriteString(fmt.Sprintf("  %s %s %v %s %v %v\n", c.Name, c.Type, c.NotNull, c.Default, c.Identity, c.Computed))
+		}
+	}
+	exp := `topics false [id]
+  id serial true  false false
+  title varchar(100) true  false false
+  tags text[] false '{}'::text[] false false
+questions false [id]
+  id bigint true  true false
+  topic_id int true  false false
+  marks numeric(6,2) false  false false
+  title text true  false false
+  score numeric false  false true
+  level int true 1 false false
+topic_questions true []
+  id bigint true  false false
+  name text true  false false
+  topic varchar(100) true  false false
+  cnt  false  false false
+  marks text false  false false
+accounts false [id]
+  id int unsigned true  true false
+  email varchar(320) true  false false
+  modified datetime false  false false
+Orders false [ID]
+  ID int true  true false
+  Amount decimal(10,2) true (0) false false
+  Total  false  false true
+  Remark nvarchar(max) false  false false
+  Status bit true 0 false false
+`
+	if got.String() != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, got.String())
+	}
+
+	ddl = `
+CREATE TABLE topics (id int PRIMARY KEY, code varchar(10) UNIQUE, title text);
+CREATE TABLE questions (
+	id int,
+	topic_id int CONSTRAINT fk_topic REFERENCES topics ON DELETE CASCADE ON UPDATE NO ACTION,
+	marks int CHECK (marks > 0),
+	CONSTRAINT uq_topic UNIQUE (topic_id, marks),
+	CONSTRAINT ck_id CHECK (id > 0 AND id < 1000),
+	FOREIGN KEY (id, topic_id) REFERENCES topics (id, code) MATCH FULL ON DELETE SET NULL
+);
+ALTER TABLE topics RENAME COLUMN code TO slug;
+ALTER TABLE topics RENAME TO subjects;
+ALTER TABLE questions DROP CONSTRAINT IF EXISTS ck_id;
+ALTER TABLE questions WITH CHECK ADD CONSTRAINT uq_marks UNIQUE (marks);
+ALTER TABLE questions DROP CONSTRAINT uq_topic, DROP CONSTRAINT pk_unknown;
+`
+	s, err = ParseDDL(strings.NewReader(ddl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Reset()
+	for _, tbl := range s.Tables() {
+		got.WriteString(fmt.Sprintf("%s %v\n", tbl.Name, tbl.PrimaryKey))
+		for _, u := range tbl.Unique {
+			got.WriteString(fmt.Sprintf("  unique %s %v\n", u.Name, u.Columns))
+		}
+		for _, c := range tbl.Checks {
+			got.WriteString(fmt.Sprintf("  check %s %s\n", c.Name, c.Expr))
+		}
+		for _, fk := range tbl.ForeignKeys {
+			got.WriteString(fmt.Sprintf("  foreign key %s %v %s %v %s %s\n", fk.Name, fk.Columns, fk.RefTable, fk.RefColumns, fk.OnDelete, fk.OnUpdate))
+		}
+	}
+	exp = `questions []
+  unique uq_marks [marks]
+  check  marks>0
+  foreign key fk_topic [topic_id] subjects [] cascade no action
+  foreign key  [id topic_id] subjects [id slug] set null 
+subjects [id]
+  unique  [slug]
+`
+	if got.String() != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, got.String())
+	}
+
+	errs := map[string]string{
+		"CREATE TABLE x (a int DEFAULT);":                        "line 1: expected default value of column 'a'",
+		"CREATE TABLE x (a int);\nALTER TABLE x ALTER COLUMN a":  "line 2: expected change of column 'a' of table 'x'",
+		"CREATE VIEW v AS SELECT":                                "line 1: expected columns of SELECT",
+		"CREATE TABLE x (a int, UNIQUE);":                        "line 1: expected columns of unique key of table 'x'",
+		"CREATE TABLE x (a int);\nALTER TABLE x DROP CONSTRAINT": "line 2: expected name of constraint of table 'x'",
+		"CREATE TABLE x (a int REFERENCES);":                     "line 1: expected name, got ''",
+		"CREATE TABLE x (a int, FOREIGN KEY (b) REFERENCES y);":  "line 1: unknown column 'b' in foreign key of table 'x'",
+		"ALTER TABLE missing ADD x int;":                         "line 1: alter of unknown table 'missing'",
+		"CREATE TABLE a (x int, PRIMARY KEY (y));":               "line 1: unknown column 'y' in primary key of table 'a'",
+		"CREATE TABLE a (x int);\nALTER TABLE a DROP y":          "line 2: drop of unknown column 'y' of table 'a'",
+		"CREATE TABLE a (x varchar(10) DEFAULT 'x);":             "line 1: unterminated string",
+	}
+	for sql, e := range errs {
+		if _, err := ParseDDL(strings.NewReader(sql)); err == nil || err.Error() != e {
+			t.Errorf("Expected\n %s\nGot\n %v", e, err)
+		}
+	}
+}
+
+func TestDDLBuilders(t *testing.T) {
+	fmt.Println("\n\nTestDDLBuilders ***")
+
+	users := CreateTableBuilder().Table("users").IfNotExists().Columns(
+		Col("id", "serial").PrimaryKey(),
+		Col("email", "varchar(320)").NotNull().Unique(),
+		Col("bio", "text"),
+		Col("active", "bool").NotNull().Default("true"),
+		Col("team_id", "int").References("teams", "id").OnDelete("cascade"),
+	).Check("email <> ''")
+	addCols := AlterTableBuilder().Table("users").AddColumn(Col("age", "smallint").Check("age > 0")).SetDefault("bio", "''")
+	dropCols := AlterTableBuilder().Table("users").DropColumn("age").DropDefault("active")
+	alterCol := AlterTableBuilder().Table("users").AlterColumn(Col("bio", "varchar(500)").NotNull())
+	rename := AlterTableBuilder().Table("users").RenameColumn("bio", "about")
+	drop := DropTableBuilder().Table("users", "teams").IfExists()
+	index := CreateIndexBuilder().On("users", "team_id", "email desc").Unique().IfNotExists()
+	legacy := CreateTableBuilder().Table("legacy").Columns(
+		Col("n", "int(11)"),
+		Col("price", "decimal(10,2)"),
+		Col("at", "timestamp(3)"),
+	)
+
+	tests := []struct {
+		b   Builder
+		exp map[string]string
+	}{
+		{users, map[string]string{
+			DbTypePostgreSQL: "create table if not exists users (id serial primary key, email varchar(320) not null unique, bio text, active boolean not null default true, team_id integer, foreign key (team_id) references teams (id) on delete cascade, check (email <> ''));",
+			DbTypeMsSQL:      "if object_id(N'users', N'U') is null create table users (id int identity(1,1) primary key, email nvarchar(320) not null unique, bio nvarchar(max), active bit not null constraint df_users_active default 1, team_id int, foreign key (team_id) references teams (id) on delete cascade, check (email <> ''));",
+			DbTypeMySQL:      "create table if not exists users (id int auto_increment primary key, email varchar(320) not null unique, bio text, active boolean not null default true, team_id int, foreign key (team_id) references teams (id) on delete cascade, check (email <> ''));",
+		}},
+		{addCols, map[string]string{
+			DbTypePostgreSQL: "alter table users add column age smallint check (age > 0), alter column bio set default '';",
+			DbTypeMsSQL:      "alter table users add age smallint check (age > 0), constraint df_users_bio default '' for bio;",
+			DbTypeMySQL:      "alter table users add column age smallint check (age > 0), alter column bio set default '';",
+		}},
+		{dropCols, map[string]string{
+			DbTypePostgreSQL: "alter table users drop column age, alter column active drop default;",
+			DbTypeMsSQL:      "alter table users drop column age, constraint df_users_active;",
+			DbTypeMySQL:      "alter table users drop column age, alter column active drop default;",
+		}},
+		{alterCol, map[string]string{
+			DbTypePostgreSQL: "alter table users alter column bio type varchar(500), alter column bio set not null;",
+			DbTypeMsSQL:      "alter table users alter column bio nvarchar(500) not null;",
+			DbTypeMySQL:      "alter table users modify column bio varchar(500) not null;",
+		}},
+		{rename, map[string]string{
+			DbTypePostgreSQL: "alter table users rename column bio to about;",
+			DbTypeMsSQL:      "exec sp_rename N'users.bio', N'about', N'COLUMN';",
+			DbTypeMySQL:      "alter table users rename column bio to about;",
+		}},
+		{drop, map[string]string{
+			DbTypePostgreSQL: "drop table if exists users, teams;",
+			DbTypeMsSQL:      "drop table if exists users, teams;",
+			DbTypeMySQL:      "drop table if exists users, teams;",
+		}},
+		{legacy, map[string]string{
+			DbTypePostgreSQL: "create table legacy (n integer, price numeric(10,2), at timestamp(3));",
+			DbTypeMsSQL:      "create table legacy (n int, price decimal(10,2), at datetime2(3));",
+			DbTypeMySQL:      "create table legacy (n int, price decimal(10,2), at datetime(3));",
+		}},
+		{index, map[string]string{
+			DbTypePostgreSQL: "create unique index if not exists ix_users_team_id_email on users (team_id, email desc);",
+			DbTypeMsSQL:      "if not exists (select * from sys.indexes where name = N'ix_users_team_id_email' and object_id = object_id(N'users')) create unique index ix_users_team_id_email on users (team_id, email desc);",
+		}},
+	}
+	for _, tc := range tests {
+		for _, dbtype := range []string{DbTypePostgreSQL, DbTypeMsSQL, DbTypeMySQL} {
+			exp, ok := tc.exp[dbtype]
+			if !ok {
+				continue
+			}
+			tc.b.setDialect(dbtype)
+			stmt := tc.b.Build(true)
+			if stmt.SQL != exp {
+				t.Errorf("Expected\n %s\nGot\n %s", exp, stmt.SQL)
+			}
+			if stmt.ReadOnly || stmt.ParamCount != 0 {
+				t.Errorf("Expected write statement without params for\n %s", stmt.SQL)
+			}
+		}
+	}
+
+	// created table reads back from its DDL
+	users.setDialect(DbTypePostgreSQL)
+	schema, err := ParseDDL(strings.NewReader(users.Build(true).SQL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl := schema.Lookup("users")
+	if tbl == nil || len(tbl.Columns) != 5 || !tbl.Column("email").NotNull || tbl.Column("active").Default != "true" || tbl.PrimaryKey[0] != "id" {
+		t.Errorf("Expected users table parsed from its DDL\nGot\n %+v", tbl)
+	}
+
+	panics := map[string]func(){
+		"rename can not be combined with other alter table actions": func() {
+			AlterTableBuilder().Table("users").RenameTo("accounts").DropColumn("bio").Build(false)
+		},
+		"add and drop can not be combined in alter table on mssql": func() {
+			b := AlterTableBuilder().Table("users").AddColumn(Col("age", "int")).DropColumn("bio")
+			b.setDialect(DbTypeMsSQL)
+			b.Build(false)
+		},
+		"if not exists of index is not applicable to mysql": func() {
+			b := CreateIndexBuilder().On("users", "email").IfNotExists()
+			b.setDialect(DbTypeMySQL)
+			b.Build(false)
+		},
+		"create index requires table name and columns": func() {
+			CreateIndexBuilder().On("users", "email", " ").Build(false)
+		},
+		"cascade is not applicable to mssql": func() {
+			b := DropTableBuilder().Table("users").Cascade()
+			b.setDialect(DbTypeMsSQL)
+			b.Build(false)
+		},
+	}
+	for exp, f := range panics {
+		func() {
+			defer func() {
+				if r := recover(); r != exp {
+					t.Errorf("Expected\n %s\nGot\n %v", exp, r)
+				}
+			}()
+			f()
+		}()
+	}
+}
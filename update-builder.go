@@ -9,7 +9,6 @@ import (
 // It allows to create UPDATE sql statements.
 func UpdateBuilder() *updateBuilder {
 	u := updateBuilder{}
-	u.calcfields = make(map[string]string)
 	u.conditionGroups = make(map[int]conditionGroup)
 	u.initEnv()
 	return &u
@@ -33,8 +32,26 @@ func (u *updateBuilder) Columns(cols ...string) *updateBuilder {
 // Can be used for inplace updation like
 //
 //	set points=points+10
+//
+// Columns are set in order of calls, calling it again for the same column replaces its value.
 func (u *updateBuilder) CalcColumn(col, value string) *updateBuilder {
-	u.calcfields[strings.Trim(col, " ")] = strings.Trim(value, " ")
+	cc := calcColumn{strings.Trim(col, " "), strings.Trim(value, " ")}
+	for i := range u.calcfields {
+		if u.calcfields[i].col == cc.col {
+			u.calcfields[i] = cc
+			return u
+		}
+	}
+	u.calcfields = append(u.calcfields, cc)
+	return u
+}
+
+// CaseColumn sets name of column/field to be updated with value of CASE expression.
+// For example
+//
+//	set status=case when points>? then 'gold' else 'silver' end
+func (u *updateBuilder) CaseColumn(col string, c *CaseExpr) *updateBuilder {
+	u.casefields = append(u.casefields, caseColumn{strings.Trim(col, " "), c})
 	return u
 }
 
@@ -90,6 +107,7 @@ func (u *updateBuilder) Returning(cols ...string) *updateBuilder {
 
 // Build generates the update sql statement along with meta information.
 func (u *updateBuilder) Build(terminateWithSemiColon bool) StatementInfo {
+	u.validateSchema(u)
 	return u.build(terminateWithSemiColon)
 }
 
@@ -97,13 +115,12 @@ func (u *updateBuilder) build(terminateWithSemiColon bool) StatementInfo {
 	var sql strings.Builder
 
 	// get count of fields
-	cnt := len(u.fields)
+	cnt := len(u.fields) + len(u.calcfields) + len(u.casefields)
 	if cnt < 1 {
 		return StatementInfo{SQL: "no fields to update"}
 	}
 
-	u.paramCounter = 0
-	u.fieldCounter = 0
+	u.reset(0)
 
 	sql.WriteString("update ")
 	sql.WriteString(u.table)
@@ -129,39 +146,27 @@ func (u *updateBuilder) build(terminateWithSemiColon bool) StatementInfo {
 		// u.fieldCounter++
 	}
 
-	for k, v := range u.calcfields {
+	for _, cc := range u.calcfields {
 		if u.fieldCounter > 0 {
 			sql.Write(comma)
 		}
-		sql.WriteString(k)
+		sql.WriteString(cc.col)
 		sql.WriteString("=")
 
-		// replace '?' with pg param i.e $1, $2 ...
-		//
-		// if param char not already "?" and also there is no need to add numbers in param
-		// then do nothing, otherwise replace parameter format
-		if strings.Contains(v, "?") && u.paramChar != "?" && u.paramNumeric {
-			tmp := strings.Split(v, "?")
-			if len(tmp) > 1 {
-				for _, str := range tmp {
-					if len(str) < 1 {
-						continue
-					}
-
-					sql.WriteString(str)
-					sql.WriteString(u.paramChar)
-					if u.paramNumeric {
-						sql.WriteString(strconv.Itoa(u.paramCounter + 1))
-					}
-					// add param to CSV
-					u.addParamToCSV(v)
-				}
-			}
-		} else {
-			sql.WriteString(v)
-		}
+		// replace '?' with param i.e $1, $2 ...
+		u.writeParamSQL(&sql, cc.value, cc.col)
 		// add field to CSV
-		u.addFieldToCSV(v)
+		u.addFieldToCSV(cc.value)
+	}
+
+	for _, cc := range u.casefields {
+		if u.fieldCounter > 0 {
+			sql.Write(comma)
+		}
+		sql.WriteString(cc.col)
+		sql.WriteString("=")
+		u.writeCase(&sql, cc.expr, cc.col)
+		u.addFieldToCSV(cc.col)
 	}
 
 	if len(u.returningFields) > 0 && u.dbtype == DbTypeMsSQL {
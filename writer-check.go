@@ -0,0 +1,236 @@
+package gosql
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+//Check renders SQL and metadata in memory like Write does, and compares it with files in given folder instead of writing them.
+//It returns unified diff of stale or missing files, or empty string when files on disk are up to date.
+//
+//It is meant for CI, to detect changed statement definitions which were not regenerated.
+func (w *FileWriter) Check(outFolder, outfileName, packageName string, option WriteOption) (string, error) {
+	files, err := w.render(outfileName, packageName, option)
+	if err != nil {
+		return "", err
+	}
+	return checkFiles(outFolder, files)
+}
+
+//CheckDialects is like Check, for files written by WriteDialects.
+func (w *FileWriter) CheckDialects(outFolder, outfileName, packageName string, option WriteOption, dialects ...string) (string, error) {
+	files, err := w.renderDialects(outfileName, packageName, option, dialects)
+	if err != nil {
+		return "", err
+	}
+	return checkFiles(outFolder, files)
+}
+
+//checkFiles returns unified diff of rendered files and files in given folder
+func checkFiles(outFolder string, files []outFile) (string, error) {
+	var diff strings.Builder
+	for _, f := range files {
+		name := path.Join(outFolder, f.name)
+		old, err := os.ReadFile(name)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		oldName := name
+		if err != nil {
+			oldName = "/dev/null"
+		}
+		diff.WriteString(unifiedDiff(oldName, name, string(old), string(f.content)))
+	}
+	return diff.String(), nil
+}
+
+//diffContext is count of unchanged lines shown around changes in unified diff
+const diffContext = 3
+
+//diffLine holds a line of diff with its kind ' ', '-' or '+'
+type diffLine struct {
+	kind byte
+	text string
+}
+
+//unifiedDiff returns unified diff of two texts, or empty string when both are same
+func unifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	lines := diffLines(splitLines(oldText), splitLines(newText))
+
+	var sb strings.Builder
+	sb.WriteString("--- " + oldName + "\n")
+	sb.WriteString("+++ " + newName + "\n")
+
+	// line numbers of old and new text at start of each diff line
+	oldLn, newLn := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	for i, l := range lines {
+		oldLn[i+1], newLn[i+1] = oldLn[i], newLn[i]
+		if l.kind != '+' {
+			oldLn[i+1]++
+		}
+		if l.kind != '-' {
+			newLn[i+1]++
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			i++
+			continue
+		}
+		// hunk starts with context before first change, and extends while changes are close enough
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end += diffContext
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			hunkRange(oldLn[start], oldLn[end]-oldLn[start]), hunkRange(newLn[start], newLn[end]-newLn[start])))
+		for _, l := range lines[start:end] {
+			sb.WriteByte(l.kind)
+			sb.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+//hunkRange returns range of lines in hunk header, starting line is 1 based unless range is empty
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+//splitLines splits text into lines, each line keeps its trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+//diffLines returns shortest edit of a to b. It uses linear space variant of Myers' algorithm, which takes time
+//proportional to size of files times count of differing lines.
+func diffLines(a, b []string) []diffLine {
+	return editLines(make([]diffLine, 0, len(a)+len(b)), a, b)
+}
+
+//editLines appends shortest edit of a to b to lines. Common prefix and suffix are trimmed, then a and b are split
+//at middle snake of their edit, so both parts take about half of the edits.
+func editLines(lines []diffLine, a, b []string) []diffLine {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	for _, s := range a[:pre] {
+		lines = append(lines, diffLine{' ', s})
+	}
+
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if len(ma) == 0 || len(mb) == 0 {
+		for _, s := range ma {
+			lines = append(lines, diffLine{'-', s})
+		}
+		for _, s := range mb {
+			lines = append(lines, diffLine{'+', s})
+		}
+	} else {
+		x, y, u, v := middleSnake(ma, mb)
+		lines = editLines(lines, ma[:x], mb[:y])
+		for _, s := range ma[x:u] {
+			lines = append(lines, diffLine{' ', s})
+		}
+		lines = editLines(lines, ma[u:], mb[v:])
+	}
+
+	for _, s := range a[len(a)-suf:] {
+		lines = append(lines, diffLine{' ', s})
+	}
+	return lines
+}
+
+//middleSnake returns start x, y and end u, v of common lines in the middle of shortest edit of a to b.
+//It searches forward from start and backward from end at once until paths of both overlap.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	off := max + 1
+	// fwd[k+off] is furthest x on diagonal k = x-y from start, bwd[c+off] is furthest distance
+	// along a from end on diagonal c = (n-x)-(m-y) from end
+	fwd := make([]int, 2*off+1)
+	bwd := make([]int, 2*off+1)
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || k != d && fwd[k-1+off] < fwd[k+1+off] {
+				x = fwd[k+1+off]
+			} else {
+				x = fwd[k-1+off] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			fwd[k+off] = u
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && u+bwd[c+off] >= n {
+				return x, y, u, v
+			}
+		}
+		for c := -d; c <= d; c += 2 {
+			var rx int
+			if c == -d || c != d && bwd[c-1+off] < bwd[c+1+off] {
+				rx = bwd[c+1+off]
+			} else {
+				rx = bwd[c-1+off] + 1
+			}
+			ry := rx - c
+			ru, rv := rx, ry
+			for ru < n && rv < m && a[n-1-ru] == b[m-1-rv] {
+				ru++
+				rv++
+			}
+			bwd[c+off] = ru
+			if k := delta - c; !odd && k >= -d && k <= d && fwd[k+off]+ru >= n {
+				return n - ru, m - rv, n - rx, m - ry
+			}
+		}
+	}
+	// not reached, paths overlap by the time max edits are searched
+	return 0, 0, 0, 0
+}
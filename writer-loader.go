@@ -0,0 +1,348 @@
+package gosql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+//loaderFormatVersion is version of JSON format written for JSON loader, it is checked by generated loader
+const loaderFormatVersion = 2
+
+//jsonLoaderFileJSON returns JSON for JSON loader with format version, database type and hash of statements
+func (w *FileWriter) jsonLoaderFileJSON() []byte {
+	statements := concatBytes("{", w.jsonBuilder.String(), "}")
+	sum := sha256.Sum256(statements)
+	return concatBytes(`{"Version":`, strconv.Itoa(loaderFormatVersion), `,"Dialect":`, strconv.Quote(w.dialect),
+		`,"Hash":"`, hex.EncodeToString(sum[:]), `","Statements":`, string(statements), "}")
+}
+
+//jsonLoaderFileContent returns GO code to load sqls from JSON
+func (w *FileWriter) jsonLoaderFileContent(pkg string) []byte {
+	code := codeHeader(pkg) + loaderImports
+	code += "// _sqlformat is version of JSON format which can be loaded\n"
+	code += "const _sqlformat = " + strconv.Itoa(loaderFormatVersion) + "\n\n"
+
+	dialects := w.dialects
+	if len(dialects) == 0 {
+		dialects = []string{""}
+	}
+	code += "// _defaultDialect is database type of statements returned by accessors until Use is called\n"
+	code += "const _defaultDialect = " + strconv.Quote(dialects[0]) + "\n\n"
+
+	code += "// _sqlparams holds keys of all statements with their count of parameters for each database type, loaded JSON must match these\n"
+	code += "var _sqlparams = map[string]map[string]int{\n"
+	for _, d := range dialects {
+		code += strconv.Quote(d) + ": {\n"
+		for _, se := range w.writequeue {
+			code += strconv.Quote(se.Key) + ": " + strconv.Itoa(w.paramCounts[d][se.Key]) + ",\n"
+		}
+		code += "},\n"
+	}
+	code += "}\n\n"
+
+	code += loaderCode
+	if len(w.dialects) > 0 {
+		code += loaderUseCode
+	}
+
+	return concatBytes(code, w.codeBuilder.String())
+}
+
+const loaderImports = `import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+`
+
+//loaderCode is static part of generated JSON loader
+const loaderCode = `// _jsonsqls holds map[string]*sqlSet of loaded statements by database type, it is replaced as a whole on every load
+var _jsonsqls atomic.Value
+
+// _reload holds map[string]func() error which load statements again from source of last successful load of each database type
+var _reload atomic.Value
+
+// _dialect holds database type of statements returned by accessors
+var _dialect atomic.Value
+
+// _loadmu serializes updates of _jsonsqls and _reload
+var _loadmu sync.Mutex
+
+// _verifyhash is 1 when loading checks hash of statements, see VerifyHash
+var _verifyhash int32
+
+//Statement holds a loaded SQL statement with its metadata
+type Statement struct {
+	//Fields holds name of comma separated fields for SELECT or UPDATE or INSERT statement.
+	Fields string
+	//FieldsCount is count of fields to be SELECT or UPDATE or INSERT.
+	FieldsCount int
+	//ParamFields holds name of comma separated fields required as parameters in SQL.
+	ParamFields string
+	//ParamCount is count of total parameters in SQL.
+	ParamCount int
+	//OutParamFields holds name of comma separated OUTPUT or INOUT parameters of stored procedure.
+	OutParamFields string
+	//ReturningFields holds name of comma separated fields returned with RETURNING clause.
+	ReturningFields string
+	//SQL is Sql statement.
+	SQL string
+	//ReadOnly tell whether the statement is ReadOnly or write to database.
+	ReadOnly bool
+}
+
+//sqlSet holds statements loaded together with hash of their JSON
+type sqlSet struct {
+	hash       string
+	statements map[string]Statement
+}
+
+//sqlFile is format of JSON file generated by gosql
+type sqlFile struct {
+	Version    int
+	Dialect    string
+	Hash       string
+	Statements json.RawMessage
+}
+
+//LoadSQLs load sql into map from json file generated by gosql.
+//The file is remembered for Reload.
+func LoadSQLs(f string) error {
+	return loadFrom(func() (io.ReadCloser, error) {
+		return os.Open(f)
+	})
+}
+
+//LoadSQLsFromFS load sql into map from json file in given file system, e.g. embed.FS.
+//The file is remembered for Reload.
+func LoadSQLsFromFS(fsys fs.FS, name string) error {
+	return loadFrom(func() (io.ReadCloser, error) {
+		return fsys.Open(name)
+	})
+}
+
+//Reload loads sql again from files of last successful LoadSQLs or LoadSQLsFromFS.
+//Statements are replaced only when new file parses and has all statements, accessors can be called concurrently meanwhile.
+func Reload() error {
+	reloads, _ := _reload.Load().(map[string]func() error)
+	if len(reloads) == 0 {
+		return errors.New("statements were not loaded from a file")
+	}
+
+	var msgs []string
+	for _, reload := range reloads {
+		if err := reload(); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		sort.Strings(msgs)
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+//WatchSQLs polls json file f at given interval, and reloads sql when its modification time or size changes.
+//Result of every reload, or failure to stat the file, is reported to hook when it is not nil.
+//Replace the file atomically as FileWriter does, a partially written file fails to load and is picked up on next change.
+//Call returned function to stop watching.
+func WatchSQLs(f string, interval time.Duration, hook func(error)) (stop func()) {
+	last, _ := os.Stat(f)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			fi, err := os.Stat(f)
+			if err != nil {
+				// report only once until file is back
+				if last != nil && hook != nil {
+					hook(err)
+				}
+				last = nil
+				continue
+			}
+			if last != nil && fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size() {
+				continue
+			}
+			last = fi
+
+			err = LoadSQLs(f)
+			if hook != nil {
+				hook(err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+//loadFrom loads sql from file opened by open, and remembers it for Reload on success
+func loadFrom(open func() (io.ReadCloser, error)) error {
+	load := func() (string, error) {
+		file, err := open()
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		return loadReader(file)
+	}
+	dialect, err := load()
+	if err != nil {
+		return err
+	}
+
+	_loadmu.Lock()
+	defer _loadmu.Unlock()
+	old, _ := _reload.Load().(map[string]func() error)
+	reloads := make(map[string]func() error, len(old)+1)
+	for d, reload := range old {
+		reloads[d] = reload
+	}
+	reloads[dialect] = func() error {
+		_, err := load()
+		return err
+	}
+	_reload.Store(reloads)
+	return nil
+}
+
+//VerifyHash makes loading fail when statements do not match hash written by gosql, e.g. after they are edited by hand.
+//It is off by default, so side-loaded files can be edited, loading checks format version and parameter counts anyway.
+func VerifyHash(verify bool) {
+	var v int32
+	if verify {
+		v = 1
+	}
+	atomic.StoreInt32(&_verifyhash, v)
+}
+
+//LoadSQLsFromReader load sql into map from json generated by gosql.
+//It fails when format version does not match, or any statement is missing or has different count of parameters,
+//or hash does not match when VerifyHash is on. Existing statements are kept then.
+func LoadSQLsFromReader(r io.Reader) error {
+	_, err := loadReader(r)
+	return err
+}
+
+//loadReader loads sql from json and returns its database type
+func loadReader(r io.Reader) (string, error) {
+	var f sqlFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return "", fmt.Errorf("parse failure: %s", err.Error())
+	}
+	if f.Version != _sqlformat {
+		return "", fmt.Errorf("unsupported format version %d, expected %d", f.Version, _sqlformat)
+	}
+	counts, ok := _sqlparams[f.Dialect]
+	if !ok {
+		return "", fmt.Errorf("unsupported database type '%s'", f.Dialect)
+	}
+	sum := sha256.Sum256(f.Statements)
+	hash := hex.EncodeToString(sum[:])
+	if atomic.LoadInt32(&_verifyhash) == 1 && f.Hash != hash {
+		return "", errors.New("hash mismatch, statements are modified or corrupt")
+	}
+
+	var sqls map[string]Statement
+	if err := json.Unmarshal(f.Statements, &sqls); err != nil {
+		return "", fmt.Errorf("parse failure: %s", err.Error())
+	}
+
+	var missing, mismatch []string
+	for key, count := range counts {
+		stmt, ok := sqls[key]
+		if !ok {
+			missing = append(missing, key)
+		} else if stmt.ParamCount != count {
+			mismatch = append(mismatch, fmt.Sprintf("%s (expected %d, got %d)", key, count, stmt.ParamCount))
+		}
+	}
+	if len(missing) > 0 || len(mismatch) > 0 {
+		sort.Strings(missing)
+		sort.Strings(mismatch)
+		msg := make([]string, 0, 2)
+		if len(missing) > 0 {
+			msg = append(msg, "missing statements: "+strings.Join(missing, ", "))
+		}
+		if len(mismatch) > 0 {
+			msg = append(msg, "parameter count mismatch: "+strings.Join(mismatch, ", "))
+		}
+		return "", errors.New(strings.Join(msg, "; "))
+	}
+
+	_loadmu.Lock()
+	defer _loadmu.Unlock()
+	old, _ := _jsonsqls.Load().(map[string]*sqlSet)
+	sets := make(map[string]*sqlSet, len(old)+1)
+	for d, set := range old {
+		sets[d] = set
+	}
+	sets[f.Dialect] = &sqlSet{hash, sqls}
+	_jsonsqls.Store(sets)
+	return f.Dialect, nil
+}
+
+//LoadedHash returns hash of loaded statements, it is empty until statements are loaded.
+//It differs from hash written by gosql when statements were edited.
+func LoadedHash() string {
+	set := loadedSet()
+	if set == nil {
+		return ""
+	}
+	return set.hash
+}
+
+//loadedSet returns statements of selected database type
+func loadedSet() *sqlSet {
+	dialect, ok := _dialect.Load().(string)
+	if !ok {
+		dialect = _defaultDialect
+	}
+	sets, _ := _jsonsqls.Load().(map[string]*sqlSet)
+	return sets[dialect]
+}
+
+func loadedStmt(key string) Statement {
+	set := loadedSet()
+	if set == nil {
+		return Statement{}
+	}
+	return set.statements[key]
+}
+
+`
+
+//loaderUseCode is part of generated JSON loader to select database type, when statements are written for more database types
+const loaderUseCode = `//Use selects database type of statements returned by accessors, e.g. Use("mssql").
+//JSON file of each database type is loaded separately, statements of selected type are empty until its file is loaded.
+func Use(dbtype string) error {
+	if _, ok := _sqlparams[dbtype]; !ok {
+		return fmt.Errorf("unsupported database type '%s'", dbtype)
+	}
+	_dialect.Store(dbtype)
+	return nil
+}
+
+`
@@ -0,0 +1,151 @@
+//Copyright (c) Santosh Gupta <github.com/samtech09>
+
+package gosql
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+)
+
+//tableMembers are names of fields and methods of generated table types, columns of same names are suffixed by 'Col'
+var tableMembers = map[string]bool{"Table": true, "Alias": true, "As": true}
+
+//WriteTables writes GO code to file '<outfileName>.go' in given folder, with a type per table and view of schema
+//holding its name and names of its columns, and a variable of that type per table, e.g. 'tbl.Questions.TopicID'.
+//Identifiers are strings, so they can be used in builders in place of literal names, and renamed or dropped columns
+//become compile errors of code using them.
+//
+//	q := tbl.Questions.As("q")
+//	gosql.SelectBuilder().Select(q.ID, q.Title).From(q.Table, q.Alias).Where(gosql.C().EQ(q.TopicID, "?"))
+func (s *Schema) WriteTables(outFolder, outfileName, packageName string) error {
+	files, err := s.renderTables(outfileName, packageName)
+	if err != nil {
+		return err
+	}
+	return writeFolder(outFolder, files)
+}
+
+//CheckTables is like WriteTables, but returns unified diff of stale file instead of writing it, see FileWriter.Check.
+func (s *Schema) CheckTables(outFolder, outfileName, packageName string) (string, error) {
+	files, err := s.renderTables(outfileName, packageName)
+	if err != nil {
+		return "", err
+	}
+	return checkFiles(outFolder, files)
+}
+
+//renderTables renders GO code of tables in memory
+func (s *Schema) renderTables(outfileName, packageName string) ([]outFile, error) {
+	var errs WriteErrors
+	if outfileName == "" {
+		errs = append(errs, fmt.Errorf("empty output file name"))
+	}
+	if !token.IsIdentifier(packageName) {
+		errs = append(errs, fmt.Errorf("invalid package name '%s' for GO code", packageName))
+	}
+
+	var code strings.Builder
+	code.WriteString(codeHeader(packageName))
+	names := make(map[string]string)
+	for _, t := range s.Tables() {
+		name, err := toIdentifier(t.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid name of table '%s': %s", t.Name, err.Error()))
+			continue
+		}
+		if other, ok := names[name]; ok {
+			errs = append(errs, fmt.Errorf("tables '%s' and '%s' have same GO name '%s'", other, t.Name, name))
+			continue
+		}
+		names[name] = t.Name
+
+		cols, err := tableFields(t)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		writeTableCode(&code, t, name, cols)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	content, err := format.Source([]byte(code.String()))
+	if err != nil {
+		return nil, WriteErrors{fmt.Errorf("generated GO code is invalid: %s", err.Error())}
+	}
+	return []outFile{{outfileName + ".go", content}}, nil
+}
+
+//tableFields returns GO field names of columns of table
+func tableFields(t *Table) ([]string, error) {
+	fields := make([]string, len(t.Columns))
+	seen := make(map[string]string, len(t.Columns))
+	for i, c := range t.Columns {
+		field, err := toIdentifier(c.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name of column '%s' of table '%s': %s", c.Name, t.Name, err.Error())
+		}
+		if tableMembers[field] {
+			field += "Col"
+		}
+		if other, ok := seen[field]; ok {
+			return nil, fmt.Errorf("columns '%s' and '%s' of table '%s' have same GO name '%s'", other, c.Name, t.Name, field)
+		}
+		seen[field] = c.Name
+		fields[i] = field
+	}
+	return fields, nil
+}
+
+//writeTableCode writes type, variable and As method of table
+func writeTableCode(code *strings.Builder, t *Table, name string, fields []string) {
+	kind := "table"
+	if t.View {
+		kind = "view"
+	}
+	typeName := name + "Table"
+
+	code.WriteString("// " + typeName + " holds names of " + kind + " '" + t.Name + "' and its columns.\n")
+	code.WriteString("type " + typeName + " struct {\n")
+	code.WriteString("// Table is name of " + kind + " and Alias is alias set by As, e.g. From(t.Table, t.Alias).\n")
+	code.WriteString("Table string\nAlias string\n\n")
+	for i, c := range t.Columns {
+		doc := "column '" + c.Name + "'"
+		if c.Type != "" {
+			doc += " " + c.Type
+		}
+		if c.NotNull {
+			doc += " not null"
+		}
+		code.WriteString("// " + fields[i] + " is " + doc + ", qualified by alias if set.\n")
+		code.WriteString(fields[i] + " string\n")
+	}
+	code.WriteString("}\n\n")
+
+	code.WriteString("// " + name + " is " + kind + " '" + t.Name + "', its columns are not qualified.\n")
+	code.WriteString("var " + name + " = " + typeName + "{Table: " + goStringLiteral(t.Name))
+	for i, c := range t.Columns {
+		code.WriteString(", " + fields[i] + ": " + goStringLiteral(c.Name))
+	}
+	code.WriteString("}\n\n")
+
+	code.WriteString("// As returns names of " + kind + " with columns qualified by given alias, e.g. '" + aliasExample(t) + "'.\n")
+	code.WriteString("func (t " + typeName + ") As(alias string) " + typeName + " {\n")
+	code.WriteString("return " + typeName + "{Table: t.Table, Alias: alias")
+	for i, c := range t.Columns {
+		code.WriteString(", " + fields[i] + ": alias + " + goStringLiteral("."+c.Name))
+	}
+	code.WriteString("}\n}\n\n")
+}
+
+//aliasExample returns example of qualified column of table for doc comment
+func aliasExample(t *Table) string {
+	alias := strings.ToLower(string([]rune(t.Name)[:1]))
+	if len(t.Columns) == 0 {
+		return alias + ".*"
+	}
+	return alias + "." + t.Columns[0].Name
+}
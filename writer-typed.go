@@ -0,0 +1,251 @@
+//Copyright (c) Santosh Gupta <github.com/samtech09>
+
+package gosql
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//wellKnownImports maps package name to import path for types of standard packages
+var wellKnownImports = map[string]string{
+	"time": "time",
+	"sql":  "database/sql",
+	"json": "encoding/json",
+}
+
+//typedParam holds name, GO field name and GO type of a parameter or field in generated struct
+type typedParam struct {
+	name   string
+	field  string
+	gotype string
+}
+
+//writeTypedCode create SQL constant, params struct and function to execute given sqlEntry and write to builder.
+//For statements returning rows it creates row struct, scan function and query function instead of execute function.
+func (w *FileWriter) writeTypedCode(se *sqlEntry) error {
+	params, err := w.typedMembers(se, "parameters", se.ParamFields, se.ParamCount, se.types.Params, "Param")
+	if err != nil {
+		return err
+	}
+
+	var fields []typedParam
+	csv, count := returnedFields(se)
+	isSelect := count > 0
+	if isSelect {
+		fields, err = w.typedMembers(se, "fields", csv, count, se.types.Fields, "Field")
+		if err != nil {
+			return err
+		}
+	}
+
+	w.writeCodeComment(se)
+	w.codeBuilder.WriteString("const " + se.Key + "SQL string = " + goStringLiteral(se.SQL) + "\n\n")
+
+	args := ""
+	if len(params) > 0 {
+		w.codeBuilder.WriteString("//" + se.Key + "Params holds parameters of " + se.Key + "SQL in order of placeholders.\n")
+		w.writeStruct(se.Key+"Params", params)
+		for _, p := range params {
+			args += ", p." + p.field
+		}
+	}
+
+	paramsArg := ""
+	if len(params) > 0 {
+		paramsArg = ", p " + se.Key + "Params"
+	}
+
+	if isSelect {
+		w.writeQueryCode(se, fields, paramsArg, args)
+		return nil
+	}
+
+	w.codeBuilder.WriteString("//" + se.Key + " executes " + se.Key + "SQL")
+	if len(params) > 0 {
+		w.codeBuilder.WriteString(" with parameters from p")
+	}
+	w.codeBuilder.WriteString(".\n")
+	w.codeBuilder.WriteString("func " + se.Key + "(ctx context.Context, db DBTX" + paramsArg + ") (sql.Result, error) {\n")
+	w.codeBuilder.WriteString("return db.ExecContext(ctx, " + se.Key + "SQL" + args + ")\n}\n\n")
+	return nil
+}
+
+//returnedFields returns comma separated names and count of fields in rows returned by statement. These are
+//ReturningFields of RETURNING or OUTPUT clause, or Fields of other statements than insert, update and delete
+//e.g. of select or exec of procedure, as Fields of those are written columns.
+func returnedFields(se *sqlEntry) (string, int) {
+	if se.ReturningFields != "" {
+		return se.ReturningFields, len(splitCSV(se.ReturningFields))
+	}
+	sql := strings.ToLower(strings.TrimSpace(se.SQL))
+	for _, verb := range []string{"insert ", "update ", "delete "} {
+		if strings.HasPrefix(sql, verb) {
+			return "", 0
+		}
+	}
+	return se.Fields, se.FieldsCount
+}
+
+//writeQueryCode writes row struct, scan function and query function of a statement returning rows.
+//When statement has rowscount field, query function allocates slice of exact capacity from first row.
+func (w *FileWriter) writeQueryCode(se *sqlEntry, fields []typedParam, paramsArg, args string) {
+	row := se.Key + "Row"
+	w.codeBuilder.WriteString("//" + row + " holds a row selected by " + se.Key + "SQL.\n")
+	w.writeStruct(row, fields)
+
+	rowscount := ""
+	dests := make([]string, len(fields))
+	for i, f := range fields {
+		dests[i] = "&r." + f.field
+		if f.name == "rowscount" {
+			rowscount = f.field
+		}
+	}
+
+	w.codeBuilder.WriteString("//Scan" + se.Key + " scans current row of rows into " + row + ".\n")
+	w.codeBuilder.WriteString("func Scan" + se.Key + "(rows *sql.Rows) (" + row + ", error) {\n")
+	w.codeBuilder.WriteString("var r " + row + "\n")
+	w.codeBuilder.WriteString("err := rows.Scan(" + strings.Join(dests, ", ") + ")\n")
+	w.codeBuilder.WriteString("return r, err\n}\n\n")
+
+	w.codeBuilder.WriteString("//Query" + se.Key + " queries " + se.Key + "SQL")
+	if paramsArg != "" {
+		w.codeBuilder.WriteString(" with parameters from p")
+	}
+	w.codeBuilder.WriteString(" and returns all rows.\n")
+	w.codeBuilder.WriteString("func Query" + se.Key + "(ctx context.Context, db DBTX" + paramsArg + ") ([]" + row + ", error) {\n")
+	w.codeBuilder.WriteString("rows, err := db.QueryContext(ctx, " + se.Key + "SQL" + args + ")\n")
+	w.codeBuilder.WriteString("if err != nil {\nreturn nil, err\n}\ndefer rows.Close()\n\n")
+	w.codeBuilder.WriteString("var items []" + row + "\n")
+	w.codeBuilder.WriteString("for rows.Next() {\n")
+	w.codeBuilder.WriteString("item, err := Scan" + se.Key + "(rows)\n")
+	w.codeBuilder.WriteString("if err != nil {\nreturn nil, err\n}\n")
+	if rowscount != "" {
+		w.codeBuilder.WriteString("if items == nil {\n")
+		w.codeBuilder.WriteString("// every row carries count of rows in resultset\n")
+		w.codeBuilder.WriteString("items = make([]" + row + ", 0, int(item." + rowscount + "))\n}\n")
+	}
+	w.codeBuilder.WriteString("items = append(items, item)\n}\n")
+	w.codeBuilder.WriteString("return items, rows.Err()\n}\n\n")
+}
+
+//writeStruct writes struct type with given fields
+func (w *FileWriter) writeStruct(name string, fields []typedParam) {
+	w.codeBuilder.WriteString("type " + name + " struct {\n")
+	for _, f := range fields {
+		w.codeBuilder.WriteString(f.field + " " + f.gotype + "\n")
+	}
+	w.codeBuilder.WriteString("}\n\n")
+}
+
+//typedMembers returns GO field names and types for comma separated names of parameters or fields of sqlEntry
+func (w *FileWriter) typedMembers(se *sqlEntry, kind, csv string, count int, types []string, fallback string) ([]typedParam, error) {
+	if len(types) > count {
+		return nil, fmt.Errorf("statement '%s' has %d %s but %d types given", se.Key, count, kind, len(types))
+	}
+
+	var names []string
+	if count > 0 {
+		names = splitCSV(csv)
+	}
+	// names may not be known for all members, fallback to numbered names then
+	numbered := len(names) != count
+
+	members := make([]typedParam, count)
+	used := make(map[string]int, count)
+	for i := range members {
+		name, field := "", ""
+		if !numbered {
+			name = exprName(names[i])
+			field = paramFieldName(name)
+		}
+		if field == "" {
+			field = fallback + strconv.Itoa(i+1)
+		}
+		used[field]++
+		if used[field] > 1 {
+			field += strconv.Itoa(used[field])
+		}
+
+		gotype := "interface{}"
+		if name == "rowscount" {
+			gotype = "int64"
+		}
+		if i < len(types) && types[i] != "" {
+			var err error
+			gotype, err = w.useType(types[i])
+			if err != nil {
+				return nil, fmt.Errorf("statement '%s': %s", se.Key, err.Error())
+			}
+		}
+		members[i] = typedParam{name, field, gotype}
+	}
+	return members, nil
+}
+
+//paramFieldName returns GO field name for parameter name like 'q.TopicID', or empty string if it is not possible
+func paramFieldName(param string) string {
+	if i := strings.LastIndex(param, "."); i >= 0 {
+		param = param[i+1:]
+	}
+	field, err := toIdentifier(param)
+	if err != nil {
+		return ""
+	}
+	return field
+}
+
+//useType records import required by GO type and returns type as to be written in code.
+//Type can be qualified by full import path e.g. '[]github.com/google/uuid.UUID' is returned as '[]uuid.UUID'.
+func (w *FileWriter) useType(gotype string) (string, error) {
+	gotype = strings.TrimSpace(gotype)
+	name := strings.TrimLeft(gotype, "*[]")
+	prefix := gotype[:len(gotype)-len(name)]
+
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 {
+		return gotype, nil
+	}
+
+	pkgpath := name[:dot]
+	pkg := pkgpath[strings.LastIndex(pkgpath, "/")+1:]
+	if !strings.Contains(pkgpath, "/") {
+		known, ok := wellKnownImports[pkgpath]
+		if !ok {
+			return "", fmt.Errorf("unknown package of type '%s', use full import path", gotype)
+		}
+		pkgpath = known
+	}
+	w.imports[pkgpath] = true
+	return prefix + pkg + name[dot:], nil
+}
+
+func (w *FileWriter) typedCodeFileContent(pkg string) []byte {
+	w.imports["context"] = true
+	w.imports["database/sql"] = true
+
+	paths := make([]string, 0, len(w.imports))
+	for p := range w.imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var header strings.Builder
+	header.WriteString(codeHeader(pkg))
+	header.WriteString("import (\n")
+	for _, p := range paths {
+		header.WriteString(strconv.Quote(p) + "\n")
+	}
+	header.WriteString(")\n\n")
+	header.WriteString(`//DBTX is implemented by *sql.DB, *sql.Tx and *sql.Conn.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}` + "\n\n")
+
+	return concatBytes(header.String(), w.codeBuilder.String())
+}
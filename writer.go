@@ -3,11 +3,16 @@
 package gosql
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"go/format"
+	"go/token"
 	"os"
 	"path"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 //WriteOption configure output files to write
@@ -20,9 +25,13 @@ const (
 	WriteGoCode
 	//WriteGoCodeAndJSON outputs SQL and metadata to JSON file and GO code file both
 	WriteGoCodeAndJSON
-	//WriteJSONandJSONLoaderGoCode outputs SQL and key to JSON and create GO code to load SQL statements from that JSON file.
-	// It is much flexible way that allows to sideload SQLs from JSON file
+	//WriteJSONandJSONLoaderGoCode outputs SQL and metadata to JSON and create GO code to load SQL statements from that JSON file.
+	// It is much flexible way that allows to sideload SQLs from JSON file.
+	// JSON holds format version, hash of statements and object per key with all fields of StatementInfo.
 	WriteJSONandJSONLoaderGoCode
+	//WriteTypedGoCode outputs SQL to GO code along with typed parameter struct and function to execute each statement
+	//e.g. 'UserCreate(ctx, db, UserCreateParams{...})', SQL constant is suffixed by 'SQL' i.e. 'UserCreateSQL'.
+	WriteTypedGoCode
 )
 
 type FileWriter struct {
@@ -31,12 +40,43 @@ type FileWriter struct {
 	codeBuilder strings.Builder
 	entry       int
 	writeoption WriteOption
+	imports     map[string]bool
+	dialect     string
+	dialects    []string
+	paramCounts map[string]map[string]int
+	schema      *Schema
+}
+
+//WriteErrors holds all errors found while rendering or writing files.
+type WriteErrors []error
+
+func (e WriteErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
 }
 
 type sqlEntry struct {
 	StatementInfo
 	Key         string
 	Description string
+	keyErr      error
+	types       GoTypes
+	builder     Builder
+}
+
+//GoTypes holds GO types of parameters and fields of a statement, used by WriteTypedGoCode.
+//Types are given in order of ParamFields and of returned fields, missing types default to interface{}.
+//Returned fields are ReturningFields when statement has them, and Fields of other statements than insert,
+//update and delete otherwise.
+//
+//Types from other packages are written as 'time.Time' for standard packages time, database/sql and encoding/json,
+//or with full import path like 'github.com/google/uuid.UUID'.
+type GoTypes struct {
+	Params []string
+	Fields []string
 }
 
 //NewFileWriter create new writer to write generated SQL and metadata to disk file.
@@ -51,181 +91,390 @@ func NewFileWriter(queueSize int) *FileWriter {
 }
 
 //Queue adds given StatementInfo to write queue for writing to file later.
+//
+//Group and key are joined to make key of statement in PascalCase e.g. group 'user' and key 'list_by_id' makes 'UserListByID'.
+//Keys that cannot be GO identifiers and duplicate keys are reported by Write.
 func (w *FileWriter) Queue(si StatementInfo, group, key, purpose string) {
-	ukey := strings.Title(group) + strings.Title(key)
-	se := sqlEntry{si, ukey, purpose}
+	ukey, err := toIdentifier(group + "_" + key)
+	if strings.TrimSpace(group+key) == "" {
+		err = fmt.Errorf("empty key for statement '%s'", purpose)
+	} else if err != nil {
+		err = fmt.Errorf("invalid key '%s' for statement '%s': %s", group+key, purpose, err.Error())
+	}
+	se := sqlEntry{si, ukey, purpose, err, GoTypes{}, nil}
 	w.writequeue = append(w.writequeue, se)
 }
 
+//QueueWithTypes is like Queue, it also sets GO types of parameters in order of ParamFields.
+//See GoTypes for format of types.
+func (w *FileWriter) QueueWithTypes(si StatementInfo, group, key, purpose string, paramTypes ...string) {
+	w.QueueTyped(si, group, key, purpose, GoTypes{Params: paramTypes})
+}
+
+//QueueTyped is like Queue, it also sets GO types of parameters and selected fields.
+//Field types are used by WriteTypedGoCode for row struct of SELECT statements.
+func (w *FileWriter) QueueTyped(si StatementInfo, group, key, purpose string, types GoTypes) {
+	w.Queue(si, group, key, purpose)
+	w.writequeue[len(w.writequeue)-1].types = types
+}
+
+//QueueBuilder adds given builder to write queue, its statement is built while writing.
+//Unlike Queue, statement can be written for more database types by WriteDialects.
+func (w *FileWriter) QueueBuilder(b Builder, group, key, purpose string) {
+	w.QueueBuilderTyped(b, group, key, purpose, GoTypes{})
+}
+
+//QueueBuilderTyped is like QueueBuilder, it also sets GO types of parameters and selected fields.
+func (w *FileWriter) QueueBuilderTyped(b Builder, group, key, purpose string, types GoTypes) {
+	w.QueueTyped(StatementInfo{}, group, key, purpose, types)
+	w.writequeue[len(w.writequeue)-1].builder = b
+}
+
+//Schema sets schema to validate statements of queued builders against, builders bound to other schema keep it.
+//Statements not matching schema are reported by Write with all problems found per statement.
+//Statements queued by Queue are already built and are not validated.
+func (w *FileWriter) Schema(s *Schema) {
+	w.schema = s
+}
+
 //Write write SQL and metadata to files 'sqlbuilder.*' in given folder.
 //
 // packageName: set package for generated GO code. If writing only to JSON file then pass empty string.
 //
+// All files are rendered in memory and validated first, then written to temporary files and renamed,
+// so a failed run never leaves partially written files behind. Returned error lists all problems found.
+//
 //    Note: existing files with same name will be overwritten in outFolder.
-func (w *FileWriter) Write(outFolder, outfileName, packageName string, option WriteOption) {
+func (w *FileWriter) Write(outFolder, outfileName, packageName string, option WriteOption) error {
+	files, err := w.render(outfileName, packageName, option)
+	if err != nil {
+		return err
+	}
+	return writeFolder(outFolder, files)
+}
+
+//WriteDialects is like Write, but builds statements queued by QueueBuilder for each of given database types
+//e.g. DbTypePostgreSQL, DbTypeMsSQL. Statements queued by Queue are written as they are for all database types.
+//
+//Files are suffixed by database type, e.g. 'sqlbuilder_pgsql.go' and 'sqlbuilder_pgsql.json'.
+//GO code files have build constraint of database type, so build with '-tags pgsql' to include one of them.
+//With WriteJSONandJSONLoaderGoCode, single GO loader 'sqlbuilder.go' is written instead, which loads JSON of
+//any of the database types and has 'Use(dbtype)' to select statements of a database type at runtime.
+func (w *FileWriter) WriteDialects(outFolder, outfileName, packageName string, option WriteOption, dialects ...string) error {
+	files, err := w.renderDialects(outfileName, packageName, option, dialects)
+	if err != nil {
+		return err
+	}
+	return writeFolder(outFolder, files)
+}
+
+//writeFolder writes rendered files to given folder, which must exist
+func writeFolder(outFolder string, files []outFile) error {
+	fi, err := os.Stat(outFolder)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a folder", outFolder)
+	}
+
+	return writeFiles(outFolder, files)
+}
+
+//outFile holds rendered content of a file to be written
+type outFile struct {
+	name    string
+	content []byte
+}
+
+//render validates queued entries and renders content of all output files in memory
+func (w *FileWriter) render(outfileName, packageName string, option WriteOption) ([]outFile, error) {
+	w.dialects = nil
+	w.paramCounts = make(map[string]map[string]int)
+	return w.renderFiles(outfileName, packageName, option, "")
+}
+
+//renderDialects renders content of output files of all given database types in memory
+func (w *FileWriter) renderDialects(outfileName, packageName string, option WriteOption, dialects []string) ([]outFile, error) {
+	var errs WriteErrors
+	if len(dialects) == 0 {
+		errs = append(errs, errors.New("at least one database type is required"))
+	}
+	seen := make(map[string]bool, len(dialects))
+	for _, d := range dialects {
+		if d != DbTypePostgreSQL && d != DbTypeMsSQL && d != DbTypeMySQL {
+			errs = append(errs, fmt.Errorf("unsupported database type '%s'", d))
+		} else if seen[d] {
+			errs = append(errs, fmt.Errorf("duplicate database type '%s'", d))
+		}
+		seen[d] = true
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	w.dialects = dialects
+	w.paramCounts = make(map[string]map[string]int)
+	var files []outFile
+	for _, d := range dialects {
+		out, err := w.renderFiles(outfileName+"_"+d, packageName, option, d)
+		if err != nil {
+			for _, e := range err.(WriteErrors) {
+				errs = append(errs, fmt.Errorf("%s: %s", d, e.Error()))
+			}
+			continue
+		}
+		if option == WriteJSONandJSONLoaderGoCode {
+			// loader is written once, when parameters of all database types are known
+			out = out[:1]
+		}
+		files = append(files, out...)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if option == WriteJSONandJSONLoaderGoCode {
+		out, err := w.renderFiles(outfileName, packageName, option, dialects[0])
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, out[1])
+	}
+	return files, nil
+}
+
+//renderFiles renders content of output files in memory, statements of queued builders are built for given database type
+//unless it is empty
+func (w *FileWriter) renderFiles(outfileName, packageName string, option WriteOption, dialect string) ([]outFile, error) {
+	var errs WriteErrors
+
+	// clear output of previous run
+	w.dialect = dialect
+	w.paramCounts[dialect] = make(map[string]int, len(w.writequeue))
 	w.writeoption = option
+	w.jsonBuilder.Reset()
+	w.codeBuilder.Reset()
+	w.entry = 0
+	w.imports = make(map[string]bool)
+
+	if outfileName == "" {
+		errs = append(errs, errors.New("output filename is required"))
+	}
+	if option != WriteJSON && !token.IsIdentifier(packageName) {
+		errs = append(errs, fmt.Errorf("invalid package name '%s' for GO code", packageName))
+	}
+
+	keys := make(map[string]string, len(w.writequeue))
+	for i := range w.writequeue {
+		se := &w.writequeue[i]
+		if se.keyErr != nil {
+			errs = append(errs, se.keyErr)
+			continue
+		}
+		if desc, ok := keys[se.Key]; ok {
+			errs = append(errs, fmt.Errorf("duplicate key '%s' for statements '%s' and '%s'", se.Key, desc, se.Description))
+			continue
+		}
+		keys[se.Key] = se.Description
+
+		if se.builder != nil {
+			if dialect != "" {
+				se.builder.setDialect(dialect)
+			}
+			if w.schema != nil {
+				se.builder.defaultSchema(w.schema)
+			}
+			si, err := buildStatement(se.builder)
+			if serr, ok := err.(SchemaErrors); ok {
+				errs = append(errs, fmt.Errorf("statement '%s' does not match schema: %s", se.Key, serr.Error()))
+				continue
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot build statement '%s': %s", se.Key, err.Error()))
+				continue
+			}
+			se.StatementInfo = si
+		}
+		w.paramCounts[dialect][se.Key] = se.ParamCount
 
-	for _, se := range w.writequeue {
 		switch option {
 		case WriteJSON:
-			w.writeJSON(&se)
+			if err := w.writeJSON(se); err != nil {
+				errs = append(errs, err)
+			}
 
 		case WriteGoCode:
-			w.writeCode(&se)
+			w.writeCode(se)
 
-		case WriteJSONandJSONLoaderGoCode:
-			if w.writeJSON(&se) {
-				w.writeCode(&se)
+		case WriteTypedGoCode:
+			if err := w.writeTypedCode(se); err != nil {
+				errs = append(errs, err)
 			}
 
-		default: //WriteGoCodeAndJSON is default
-			if w.writeJSON(&se) {
-				w.writeCode(&se)
+		default: //WriteGoCodeAndJSON is default, WriteJSONandJSONLoaderGoCode also writes both
+			if err := w.writeJSON(se); err != nil {
+				errs = append(errs, err)
+				continue
 			}
-
+			w.writeCode(se)
 		}
 	}
 
-	// write output file
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// prepare output files
+	jsonFile := outFile{outfileName + ".json", nil}
+	codeFile := outFile{outfileName + ".go", nil}
 	switch option {
 	case WriteJSON:
-		w.writeJSONFile(path.Join(outFolder, outfileName+".json"))
+		jsonFile.content = w.jsonFileContent()
+		return []outFile{jsonFile}, nil
 
 	case WriteGoCode:
-		w.writeCodeFile(packageName, path.Join(outFolder, outfileName+".go"))
+		codeFile.content = w.codeFileContent(packageName)
+
+	case WriteTypedGoCode:
+		codeFile.content = w.typedCodeFileContent(packageName)
 
 	case WriteJSONandJSONLoaderGoCode:
-		w.writeJSONLoaderFile(outFolder, outfileName, packageName)
+		jsonFile.content = w.jsonLoaderFileJSON()
+		codeFile.content = w.jsonLoaderFileContent(packageName)
 
 	default: //WriteGoCodeAndJSON is default
-		w.writeJSONFile(path.Join(outFolder, outfileName+".json"))
-		w.writeCodeFile(packageName, path.Join(outFolder, outfileName+".go"))
+		jsonFile.content = w.jsonFileContent()
+		codeFile.content = w.codeFileContent(packageName)
+	}
 
+	if dialect != "" && option != WriteJSONandJSONLoaderGoCode {
+		// only GO code of database type chosen by build tag is compiled
+		codeFile.content = concatBytes("//go:build ", dialect, "\n// +build ", dialect, "\n\n", string(codeFile.content))
 	}
-}
 
-func (w *FileWriter) writeJSONFile(file string) {
-	writeFile(file, "[", "]", &w.jsonBuilder)
-}
+	// format GO code as gofmt does, it also makes sure that generated code parses
+	code, err := format.Source(codeFile.content)
+	if err != nil {
+		return nil, WriteErrors{fmt.Errorf("generated GO code is invalid: %s", err.Error())}
+	}
+	codeFile.content = code
 
-func (w *FileWriter) writeCodeFile(pkg, file string) {
-	header := "//" + pkg + " package is autogenerated by gosql.\n//Do not edit this file.\n//Changes will be lost on next auto-generate.\n"
-	header += "package " + pkg + "\n\n"
-	writeFile(file, header, "", &w.codeBuilder)
+	if option == WriteGoCode || option == WriteTypedGoCode {
+		return []outFile{codeFile}, nil
+	}
+	return []outFile{jsonFile, codeFile}, nil
 }
 
-//writeJSONLoaderFile write json file as well as GO code to load sqls from JSON
-func (w *FileWriter) writeJSONLoaderFile(folder, filename, pkg string) {
-	writeFile(path.Join(folder, filename+".json"), "{", "}", &w.jsonBuilder)
-
-	header := "//" + pkg + " package is autogenerated by gosql.\n//Do not edit this file.\n//Changes will be lost on next auto-generate.\n"
-	header += "package " + pkg + "\n\n"
-	header += `import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"os"
-)
+//buildStatement builds statement of given builder, misuse of builder for its database type is returned as error
+func buildStatement(b Builder) (si StatementInfo, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if serr, ok := r.(SchemaErrors); ok {
+				err = serr
+				return
+			}
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return b.Build(true), nil
+}
 
-var _jsonsqls map[string]string
+//codeHeader returns header of generated GO code, marked as generated so linters and tools skip it
+func codeHeader(pkg string) string {
+	return "// Code generated by gosql. DO NOT EDIT.\n\npackage " + pkg + "\n\n"
+}
 
-type Statement struct {
-	ReadOnly  bool
-	SQL       string
+func (w *FileWriter) jsonFileContent() []byte {
+	return concatBytes("[", w.jsonBuilder.String(), "]")
 }
 
-func fatal(msg ...string) {
-	fmt.Println(msg)
-	os.Exit(1)
+func (w *FileWriter) codeFileContent(pkg string) []byte {
+	return concatBytes(codeHeader(pkg), w.codeBuilder.String())
 }
 
-//LoadSQLs load sql into map from json file generated by gosql
-func LoadSQLs(f string) {
-	file, err := os.Open(f)
-	if err != nil {
-		fatal("error reading file ", f, " ", err.Error())
+//writeFiles writes all files to temporary files in folder first, then renames them to actual names.
+//If any temporary file cannot be written, none of the existing files is replaced.
+func writeFiles(folder string, files []outFile) error {
+	temps := make([]string, 0, len(files))
+	cleanup := func() {
+		for _, tmp := range temps {
+			os.Remove(tmp)
+		}
 	}
-	defer file.Close()
 
-	byteValue, err := ioutil.ReadAll(file)
-	if err != nil {
-		fatal("read failure ", err.Error())
+	for _, f := range files {
+		tmp, err := writeTempFile(folder, f)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		temps = append(temps, tmp)
 	}
 
-	err = json.Unmarshal([]byte(byteValue), &_jsonsqls)
-	if err != nil {
-		fatal("parse failure ", err.Error())
+	var errs WriteErrors
+	for i, f := range files {
+		if err := os.Rename(temps[i], path.Join(folder, f.name)); err != nil {
+			errs = append(errs, err)
+			os.Remove(temps[i])
+		}
 	}
-}
-
-func parseStmt(s string) Statement {
-	stmt := Statement{}
-	ro := s[:1]
-	if ro == "T" {
-		stmt.ReadOnly = true
+	if len(errs) > 0 {
+		return errs
 	}
-	stmt.SQL = s[2:len(s)]
-	return stmt
-}` + "\n\n"
-
-	writeFile(path.Join(folder, filename+".go"), header, "", &w.codeBuilder)
-
+	return nil
 }
 
-func writeFile(file, header, footer string, content *strings.Builder) {
-	f, err := os.Create(file)
+//writeTempFile writes content to a new temporary file in folder and returns its name
+func writeTempFile(folder string, f outFile) (string, error) {
+	tmp, err := os.CreateTemp(folder, "."+f.name+".tmp")
 	if err != nil {
-		panic(err)
+		return "", err
 	}
-	defer f.Close()
 
-	// writer header
-	_, err = f.WriteString(header)
-	if err != nil {
-		panic(err)
+	_, err = tmp.Write(f.content)
+	if err == nil {
+		err = tmp.Sync()
 	}
-
-	// write content
-	_, err = f.WriteString(content.String())
-	if err != nil {
-		panic(err)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
 	}
-
-	// writer footer
-	_, err = f.WriteString(footer)
-	if err != nil {
-		panic(err)
+	if err == nil {
+		// temp files are created with 0600, keep usual permission of source files
+		err = os.Chmod(tmp.Name(), 0644)
 	}
-
-	err = f.Sync()
 	if err != nil {
-		panic(err)
+		os.Remove(tmp.Name())
+		return "", err
 	}
+	return tmp.Name(), nil
 }
 
 //writeJSON create and write JSON to builder for given sqlEntry
-func (w *FileWriter) writeJSON(se *sqlEntry) bool {
-	json, err := structToJSONString(se)
+func (w *FileWriter) writeJSON(se *sqlEntry) error {
+	jsonstr, err := structToJSONString(se)
 	if err != nil {
-		fmt.Printf("Error writing [%s] : %s", se.Key, err.Error())
-		return false
+		return fmt.Errorf("error writing [%s] : %s", se.Key, err.Error())
 	}
 
 	if w.entry > 0 {
 		w.jsonBuilder.WriteString(",")
 	}
 	if w.writeoption == WriteJSONandJSONLoaderGoCode {
-		readonly := "T"
-		if !se.ReadOnly {
-			readonly = "F"
+		key, _ := json.Marshal(se.Key)
+		value, err := json.Marshal(se.StatementInfo)
+		if err != nil {
+			return fmt.Errorf("error writing [%s] : %s", se.Key, err.Error())
 		}
-		w.jsonBuilder.WriteString(fmt.Sprintf("\"%s\":\"%s|%s\"", se.Key, readonly, se.SQL))
+		w.jsonBuilder.Write(key)
+		w.jsonBuilder.WriteString(":")
+		w.jsonBuilder.Write(value)
 	} else {
-		w.jsonBuilder.WriteString(json)
+		w.jsonBuilder.WriteString(jsonstr)
 	}
 	w.entry++
 
-	return true
+	return nil
 }
 
 // writeCode create code block for given sqlEntry and write to builder
@@ -234,21 +483,17 @@ func (w *FileWriter) writeCode(se *sqlEntry) {
 
 	if w.writeoption == WriteJSONandJSONLoaderGoCode {
 		w.codeBuilder.WriteString(fmt.Sprintf(`func %s() Statement {
-	sql, ok := _jsonsqls["%s"]
-	if !ok {
-		return Statement{}
-	}
-	return parseStmt(sql)
-}`, se.Key, se.Key))
+	return loadedStmt(%s)
+}`, se.Key, strconv.Quote(se.Key)))
 		w.codeBuilder.WriteString("\n\n")
 	} else {
-		w.codeBuilder.WriteString("const " + se.Key + " string = \"" + se.SQL + "\"\n\n")
+		w.codeBuilder.WriteString("const " + se.Key + " string = " + goStringLiteral(se.SQL) + "\n\n")
 	}
 }
 
 //writeCodeComment writes comments for code in builder
 func (w *FileWriter) writeCodeComment(se *sqlEntry) {
-	w.codeBuilder.WriteString("//" + se.Description + "\n")
+	w.writeCommentLines("//", se.Description)
 
 	w.codeBuilder.WriteString("//\n")
 	w.codeBuilder.WriteString("//Fields: " + strconv.Itoa(se.FieldsCount))
@@ -264,6 +509,11 @@ func (w *FileWriter) writeCodeComment(se *sqlEntry) {
 		w.codeBuilder.WriteString("//  ParamFields: " + se.ParamFields + "\n")
 	}
 
+	if len(se.OutParamFields) > 0 {
+		w.codeBuilder.WriteString("//\n")
+		w.codeBuilder.WriteString("//  OutParamFields: " + se.OutParamFields + "\n")
+	}
+
 	if len(se.ReturningFields) > 0 {
 		w.codeBuilder.WriteString("//\n")
 		w.codeBuilder.WriteString("//  ReturningFields: " + se.ReturningFields + "\n")
@@ -271,6 +521,22 @@ func (w *FileWriter) writeCodeComment(se *sqlEntry) {
 
 	if w.writeoption == WriteJSONandJSONLoaderGoCode {
 		w.codeBuilder.WriteString("//SQL:\n")
-		w.codeBuilder.WriteString("//  " + se.SQL + "\n")
+		w.writeCommentLines("//  ", se.SQL)
+	}
+}
+
+//writeCommentLines writes each line of text as comment with given prefix, so multi-line text cannot break out of comment
+func (w *FileWriter) writeCommentLines(prefix, text string) {
+	for _, line := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n") {
+		w.codeBuilder.WriteString(prefix + line + "\n")
+	}
+}
+
+//goStringLiteral returns GO string literal for s. Multi-line strings are written as raw string when possible
+//to keep SQL readable, otherwise as double-quoted string with escaping.
+func goStringLiteral(s string) string {
+	if strings.Contains(s, "\n") && !strings.ContainsAny(s, "`\r\x00") && utf8.ValidString(s) && !strings.ContainsRune(s, '\ufeff') {
+		return "`" + s + "`"
 	}
+	return strconv.Quote(s)
 }
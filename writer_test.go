@@ -3,8 +3,21 @@
 package gosql
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
 	"os"
+	"os/exec"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -44,7 +57,10 @@ func TestWriteJSON(t *testing.T) {
 	fw.Queue(stmt1, "Ques", "qlist", "Gives list of questions to populate dropdown")
 	fw.Queue(stmt2, "Ques", "qdata", "Gives complete question data for all questions")
 
-	fw.Write("/tmp", "sqlbuilder", "sqltest", WriteGoCodeAndJSON)
+	err := fw.Write(os.TempDir(), "sqlbuilder", "sqltest", WriteGoCodeAndJSON)
+	if err != nil {
+		t.Error(err)
+	}
 }
 
 func TestWriteJSONLoader(t *testing.T) {
@@ -81,6 +97,901 @@ func TestWriteJSONLoader(t *testing.T) {
 	fw.Queue(stmt1, "Ques", "qlist", "Gives list of questions to populate dropdown")
 	fw.Queue(stmt2, "Ques", "qdata", "Gives complete question data for all questions")
 
-	fw.Write("/tmp", "sqlloader", "sqltest", WriteJSONandJSONLoaderGoCode)
+	err := fw.Write(os.TempDir(), "sqlloader", "sqltest", WriteJSONandJSONLoaderGoCode)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	fmt.Println("\n\nTestWriteErrors ***")
+
+	dir, err := os.MkdirTemp("", "gosql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stmt := InsertBuilder().Table("users").Columns("name", "age").Build(true)
+
+	fw := NewFileWriter(5)
+	fw.Queue(stmt, "", "", "Create new user")
+
+	// empty package name and empty key are both reported
+	err = fw.Write(dir, "sqlbuilder", "", WriteGoCode)
+	errs, ok := err.(WriteErrors)
+	if !ok || len(errs) != 2 {
+		t.Errorf("Expected 2 errors\nGot\n %v", err)
+	}
+
+	fw = NewFileWriter(5)
+	fw.Queue(stmt, "user", "create", "Create new user")
+
+	err = fw.Write(path.Join(dir, "missing"), "sqlbuilder", "sqls", WriteGoCode)
+	if err == nil {
+		t.Errorf("Expected error for missing folder")
+	}
+
+	// nothing is left behind by failed runs
+	files, _ := os.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("Expected no files in output folder\nGot\n %d", len(files))
+	}
+
+	err = fw.Write(dir, "sqlbuilder", "sqls", WriteGoCodeAndJSON)
+	if err != nil {
+		t.Error(err)
+	}
+	files, _ = os.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("Expected 2 files in output folder\nGot\n %d", len(files))
+	}
+}
+
+func TestQueueKeys(t *testing.T) {
+	fmt.Println("\n\nTestQueueKeys ***")
+
+	keys := map[string]string{
+		"ques|listForDD":  "QuesListForDD",
+		"user|user_id":    "UserUserID",
+		"user|by-email":   "UserByEmail",
+		"api|json data":   "APIJSONData",
+		"Ques|qlist":      "QuesQlist",
+		"order_item|list": "OrderItemList",
+	}
+	for in, exp := range keys {
+		parts := strings.Split(in, "|")
+		fw := NewFileWriter(1)
+		fw.Queue(StatementInfo{}, parts[0], parts[1], "")
+		if fw.writequeue[0].Key != exp {
+			t.Errorf("Expected\n %s\nGot\n %s", exp, fw.writequeue[0].Key)
+		}
+	}
+
+	stmt := InsertBuilder().Table("users").Columns("name").Build(true)
+
+	fw := NewFileWriter(5)
+	fw.Queue(stmt, "user", "create", "Create new user")
+	fw.Queue(stmt, "user", "Create", "Create user from signup")
+	fw.Queue(stmt, "user", "2fa", "Enable 2FA")
+	fw.Queue(stmt, "", "9lives", "Invalid identifier")
+	fw.Queue(stmt, "user", "delete!", "Delete user")
+
+	_, err := fw.render("sqlbuilder", "sqls", WriteGoCode)
+	errs, ok := err.(WriteErrors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("Expected 3 errors\nGot\n %v", err)
+	}
+	exp := "duplicate key 'UserCreate' for statements 'Create new user' and 'Create user from signup'"
+	if errs[0].Error() != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, errs[0].Error())
+	}
+}
+
+func TestWriteEscaping(t *testing.T) {
+	fmt.Println("\n\nTestWriteEscaping ***")
+
+	sqls := map[string]string{
+		"Quotes":    `select "Title", data->>'name' from "Questions" where title like '%\\%';`,
+		"Multiline": "select id,\n  title\nfrom questions\nwhere title like '`%';",
+		"Raw":       "select id,\n\t\"title\" -- \\n\nfrom questions;",
+	}
+
+	fw := NewFileWriter(3)
+	for key, sql := range sqls {
+		fw.Queue(StatementInfo{SQL: sql}, "esc", key, "Escaping "+key+"\nsecond line")
+	}
+
+	files, err := fw.render("sqlbuilder", "sqls", WriteGoCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// parse generated code and compare value of constants
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "sqlbuilder.go", files[0].content, 0)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %s\n%s", err, files[0].content)
+	}
+	found := 0
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			got, err := strconv.Unquote(vs.Values[0].(*ast.BasicLit).Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exp := sqls[strings.TrimPrefix(vs.Names[0].Name, "Esc")]
+			if got != exp {
+				t.Errorf("Expected\n %s\nGot\n %s", exp, got)
+			}
+			found++
+		}
+	}
+	if found != len(sqls) {
+		t.Errorf("Expected %d constants\nGot\n %d", len(sqls), found)
+	}
+	if !strings.Contains(string(files[0].content), "\nconst EscRaw string = `select id,\n") {
+		t.Errorf("Expected multi-line SQL as raw string\n%s", files[0].content)
+	}
+
+	// side-loaded JSON must parse back to same SQL
+	files, err = fw.render("sqlbuilder", "sqls", WriteJSONandJSONLoaderGoCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded struct {
+		Version    int
+		Statements map[string]StatementInfo
+	}
+	if err := json.Unmarshal(files[0].content, &loaded); err != nil {
+		t.Fatalf("generated JSON does not parse: %s\n%s", err, files[0].content)
+	}
+	if loaded.Version != loaderFormatVersion {
+		t.Errorf("Expected\n %d\nGot\n %d", loaderFormatVersion, loaded.Version)
+	}
+	for key, sql := range sqls {
+		if loaded.Statements["Esc"+key].SQL != sql {
+			t.Errorf("Expected\n %s\nGot\n %s", sql, loaded.Statements["Esc"+key].SQL)
+		}
+	}
+	if _, err := parser.ParseFile(fset, "sqlbuilder.go", files[1].content, 0); err != nil {
+		t.Errorf("generated loader code does not parse: %s\n%s", err, files[1].content)
+	}
+}
+
+func TestWriteGoFormat(t *testing.T) {
+	fmt.Println("\n\nTestWriteGoFormat ***")
+
+	stmt := SelectBuilder().Select("q.ID", "qd.Title").
+		From("Questions", "q").
+		Where(C().EQ("q.TopicID", "?")).
+		Build(true)
+
+	fw := NewFileWriter(1)
+	fw.Queue(stmt, "ques", "list", "Gives list of questions")
+
+	for _, option := range []WriteOption{WriteGoCode, WriteJSONandJSONLoaderGoCode} {
+		files, err := fw.render("sqlbuilder", "sqls", option)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := files[len(files)-1].content
+
+		formatted, err := format.Source(code)
+		if err != nil || !bytes.Equal(formatted, code) {
+			t.Errorf("Expected gofmt clean code\nGot\n%s", code)
+		}
+		if !bytes.HasPrefix(code, []byte("// Code generated by gosql. DO NOT EDIT.\n")) {
+			t.Errorf("Expected generated code header\nGot\n%s", code)
+		}
+	}
+}
+
+func TestWriteTypedGoCode(t *testing.T) {
+	fmt.Println("\n\nTestWriteTypedGoCode ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	proc := ProcBuilder().Select("id", "name").FromProc("findusers").Param("name")
+	proc.setDialect(DbTypeMsSQL)
+
+	fw := NewFileWriter(6)
+	fw.QueueWithTypes(InsertBuilder().Table("users").Columns("name", "age", "joined_on").Build(true),
+		"user", "create", "Create new user", "string", "int", "time.Time")
+	fw.QueueWithTypes(UpdateBuilder().Table("users").Columns("name").CalcColumn("points", "points+?").CalcColumn("rank", "coalesce(rank, ?)").
+		Where(C().EQ("id", "?"), C().EQ("u.name", "?")).Build(true),
+		"user", "update", "Update user", "string", "*int", "int", "int64")
+	fw.Queue(DeleteBuilder().Table("users").Build(true), "user", "deleteAll", "Delete all users")
+	fw.QueueWithTypes(SelectBuilder().Select("cast(? as int) as n").From("users", "u").GroupBy("date_trunc(?, u.joined_on)").Build(true),
+		"user", "stats", "User stats", "string", "string")
+	fw.QueueWithTypes(InsertBuilder().Table("users").Columns("name").Returning("id").Build(true), "user", "add", "Add user", "string")
+	fw.QueueWithTypes(proc.Build(true), "user", "find", "Find users by name", "string")
+
+	files, err := fw.render("sqlbuilder", "sqls", WriteTypedGoCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(files[0].content)
+
+	exp := []string{
+		"type UserCreateParams struct {\n\tName     string\n\tAge      int\n\tJoinedOn time.Time\n}",
+		"func UserCreate(ctx context.Context, db DBTX, p UserCreateParams) (sql.Result, error) {\n\treturn db.ExecContext(ctx, UserCreateSQL, p.Name, p.Age, p.JoinedOn)\n}",
+		"type UserUpdateParams struct {\n\tName   string\n\tPoints *int\n\tRank   int\n\tID     int64\n\tName2  interface{}\n}",
+		"func UserDeleteAll(ctx context.Context, db DBTX) (sql.Result, error) {\n\treturn db.ExecContext(ctx, UserDeleteAllSQL)\n}",
+		"type UserStatsParams struct {\n\tN      string\n\tParam2 string\n}",
+		"func QueryUserAdd(ctx context.Context, db DBTX, p UserAddParams) ([]UserAddRow, error) {\n\trows, err := db.QueryContext(ctx, UserAddSQL, p.Name)",
+		"type UserAddRow struct {\n\tID interface{}\n}",
+		"func QueryUserFind(ctx context.Context, db DBTX, p UserFindParams) ([]UserFindRow, error) {\n\trows, err := db.QueryContext(ctx, UserFindSQL, p.Name)",
+		"type UserFindRow struct {\n\tID   interface{}\n\tName interface{}\n}",
+	}
+	for _, e := range exp {
+		if !strings.Contains(code, e) {
+			t.Errorf("Expected\n%s\nGot\n%s", e, code)
+		}
+	}
+
+	// generated code must type-check
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "sqlbuilder.go", files[0].content, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("sqls", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("generated code does not compile: %s\n%s", err, code)
+	}
+
+	// wrong count of types is reported
+	fw = NewFileWriter(1)
+	fw.QueueWithTypes(InsertBuilder().Table("users").Columns("name").Build(true), "user", "create", "", "string", "int")
+	if _, err := fw.render("sqlbuilder", "sqls", WriteTypedGoCode); err == nil {
+		t.Errorf("Expected error for extra parameter types")
+	}
+
+	gotype, err := fw.useType("[]github.com/google/uuid.UUID")
+	if err != nil || gotype != "[]uuid.UUID" || !fw.imports["github.com/google/uuid"] {
+		t.Errorf("Expected\n %s\nGot\n %s %v", "[]uuid.UUID", gotype, err)
+	}
+}
+
+func TestWriteTypedRows(t *testing.T) {
+	fmt.Println("\n\nTestWriteTypedRows ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	fw := NewFileWriter(2)
+	fw.QueueTyped(SelectBuilder().Select("u.id", "u.name", "coalesce(u.age, 0) as age").From("users", "u").
+		Where(C().GT("u.age", "?")).RowCount().Build(true),
+		"user", "list", "List users", GoTypes{Params: []string{"int"}, Fields: []string{"int64", "sql.NullString", "int"}})
+	fw.Queue(SelectBuilder().Select("id").From("users", "u").Build(true), "user", "ids", "List user ids")
+
+	files, err := fw.render("sqlbuilder", "sqls", WriteTypedGoCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(files[0].content)
+
+	exp := []string{
+		"type UserListRow struct {\n\tID        int64\n\tName      sql.NullString\n\tAge       int\n\tRowscount int64\n}",
+		"func ScanUserList(rows *sql.Rows) (UserListRow, error) {\n\tvar r UserListRow\n\terr := rows.Scan(&r.ID, &r.Name, &r.Age, &r.Rowscount)",
+		"func QueryUserList(ctx context.Context, db DBTX, p UserListParams) ([]UserListRow, error) {\n\trows, err := db.QueryContext(ctx, UserListSQL, p.Age)",
+		"items = make([]UserListRow, 0, int(item.Rowscount))",
+		"type UserIdsRow struct {\n\tID interface{}\n}",
+		"func QueryUserIds(ctx context.Context, db DBTX) ([]UserIdsRow, error) {",
+	}
+	for _, e := range exp {
+		if !strings.Contains(code, e) {
+			t.Errorf("Expected\n%s\nGot\n%s", e, code)
+		}
+	}
+	if strings.Contains(code, "func UserList(") {
+		t.Errorf("Expected no exec function for select statement\n%s", code)
+	}
+
+	// generated code must type-check
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "sqlbuilder.go", files[0].content, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("sqls", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("generated code does not compile: %s\n%s", err, code)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	fmt.Println("\n\nTestCheck ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+
+	dir, err := os.MkdirTemp("", "gosql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fw := NewFileWriter(2)
+	fw.Queue(InsertBuilder().Table("users").Columns("name", "age").Build(true), "user", "create", "Create new user")
+	fw.Queue(DeleteBuilder().Table("users").Where(C().EQ("id", "?")).Build(true), "user", "delete", "Delete user")
+
+	// missing files are reported
+	diff, err := fw.Check(dir, "sqlbuilder", "sqls", WriteGoCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(diff, "--- /dev/null\n+++ "+path.Join(dir, "sqlbuilder.go")+"\n@@ -0,0 +1,") {
+		t.Errorf("Expected diff of missing file\nGot\n %s", diff)
+	}
+
+	if err := fw.Write(dir, "sqlbuilder", "sqls", WriteGoCode); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = fw.Check(dir, "sqlbuilder", "sqls", WriteGoCode)
+	if err != nil || diff != "" {
+		t.Errorf("Expected no diff\nGot\n %s %v", diff, err)
+	}
+
+	// changed definition is reported
+	fw = NewFileWriter(2)
+	fw.Queue(InsertBuilder().Table("users").Columns("name", "age", "email").Build(true), "user", "create", "Create new user")
+	fw.Queue(DeleteBuilder().Table("users").Where(C().EQ("id", "?")).Build(true), "user", "delete", "Delete user")
+	diff, err = fw.Check(dir, "sqlbuilder", "sqls", WriteGoCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{
+		"-const UserCreate string = \"insert into users(name, age) values($1, $2);\"\n",
+		"+const UserCreate string = \"insert into users(name, age, email) values($1, $2, $3);\"\n",
+	}
+	for _, e := range exp {
+		if !strings.Contains(diff, e) {
+			t.Errorf("Expected\n %s\nGot\n %s", e, diff)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	fmt.Println("\n\nTestUnifiedDiff ***")
+
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn"
+	exp := "--- old\n+++ new\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -11,3 +11,4 @@\n k\n l\n m\n+n\n\\ No newline at end of file\n"
+	got := unifiedDiff("old", "new", old, new)
+	if got != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, got)
+	}
+
+	// large files differing in scattered lines get shortest edit
+	var a, b []string
+	for i := 0; i < 20000; i++ {
+		line := strconv.Itoa(i)
+		a = append(a, line)
+		switch {
+		case i%1000 == 500:
+			b = append(b, line+"x")
+		case i%1000 != 700:
+			b = append(b, line)
+		}
+	}
+	edits, same := 0, 0
+	var gotA, gotB []string
+	for _, l := range diffLines(a, b) {
+		if l.kind != ' ' {
+			edits++
+		} else {
+			same++
+		}
+		if l.kind != '+' {
+			gotA = append(gotA, l.text)
+		}
+		if l.kind != '-' {
+			gotB = append(gotB, l.text)
+		}
+	}
+	if edits != 60 || same != 19960 || strings.Join(gotA, "\n") != strings.Join(a, "\n") || strings.Join(gotB, "\n") != strings.Join(b, "\n") {
+		t.Errorf("Expected 60 edits and 19960 common lines\nGot\n %d %d", edits, same)
+	}
+}
+
+func TestJSONLoaderCode(t *testing.T) {
+	fmt.Println("\n\nTestJSONLoaderCode ***")
+
+	dir, err := os.MkdirTemp("", "gosql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fw := NewFileWriter(2)
+	fw.Queue(InsertBuilder().Table("users").Columns("name").Build(true), "user", "create", "Create new user")
+	fw.Queue(SelectBuilder().Select("id").From("users", "").Build(true), "user", "list", "List users")
+	if err := os.Mkdir(path.Join(dir, "sqls"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Write(path.Join(dir, "sqls"), "sqlbuilder", "sqls", WriteJSONandJSONLoaderGoCode); err != nil {
+		t.Fatal(err)
+	}
+
+	// load generated JSON from file, file system and reader, then from broken sources
+	main := `package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"loadertest/sqls"
+)
+
+// sqlJSON returns JSON in format of gosql with given statements, hash is not updated as for edited files
+func sqlJSON(statements string) string {
+	return "{\"Version\":2,\"Hash\":\"00\",\"Statements\":" + statements + "}"
+}
+
+func main() {
+	fmt.Println(sqls.LoadSQLs("sqls/sqlbuilder.json"), sqls.UserList().ReadOnly, sqls.UserList().Fields)
+	fmt.Println(sqls.LoadSQLsFromFS(os.DirFS("sqls"), "sqlbuilder.json"), sqls.UserCreate().ReadOnly, sqls.UserCreate().ParamCount)
+	fmt.Println(len(sqls.LoadedHash()))
+	fmt.Println(sqls.LoadSQLsFromReader(strings.NewReader("{\"UserCreate\":\"F|x\"}")))
+	fmt.Println(sqls.LoadSQLsFromReader(strings.NewReader(strings.Replace(sqlJSON("{}"), "2", "3", 1))))
+	sqls.VerifyHash(true)
+	fmt.Println(sqls.LoadSQLsFromReader(strings.NewReader(sqlJSON("{\"UserCreate\":{\"ParamCount\":1},\"UserList\":{}}"))))
+	sqls.VerifyHash(false)
+	fmt.Println(sqls.LoadSQLsFromReader(strings.NewReader(sqlJSON("{\"UserCreate\":{\"ParamCount\":2}}"))))
+	fmt.Println(sqls.UserCreate().SQL != "")
+	fmt.Println(sqls.LoadSQLs("missing.json") != nil)
+
+	// reload changed file from last source
+	os.WriteFile("sqls/sqlbuilder.json", []byte(sqlJSON("{\"UserCreate\":{\"ParamCount\":1},\"UserList\":{\"SQL\":\"select id from members;\"}}")), 0644)
+	fmt.Println(sqls.Reload(), sqls.UserList().SQL)
+
+	// broken file is reported to hook and statements are kept, watcher has seen the file before it returns
+	results := make(chan error, 1)
+	stop := sqls.WatchSQLs("sqls/sqlbuilder.json", 10*time.Millisecond, func(err error) { results <- err })
+	defer stop()
+	os.WriteFile("sqls/broken.json", []byte("{}"), 0644)
+	os.Rename("sqls/broken.json", "sqls/sqlbuilder.json")
+	select {
+	case err := <-results:
+		fmt.Println(err, sqls.UserList().SQL)
+	case <-time.After(10 * time.Second):
+		fmt.Println("timeout waiting for reload")
+	}
+}
+`
+	out := runGoMain(t, dir, main)
+	exp := "<nil> true id\n<nil> false 1\n64\n" +
+		"unsupported format version 0, expected 2\n" +
+		"unsupported format version 3, expected 2\n" +
+		"hash mismatch, statements are modified or corrupt\n" +
+		"missing statements: UserList; parameter count mismatch: UserCreate (expected 1, got 2)\n" +
+		"true\ntrue\n" +
+		"<nil> select id from members;\n" +
+		"unsupported format version 0, expected 2 select id from members;\n"
+	if string(out) != exp {
+		t.Errorf("Expected\n %s\nGot\n %s", exp, out)
+	}
+}
+
+// runGoMain runs given main package in dir, which has generated package 'loadertest/sqls' in folder sqls
+func runGoMain(t *testing.T, dir, main string) string {
+	if testing.Short() {
+		t.Skip("runs go toolchain")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	files := map[string]string{
+		"go.mod":  "module loadertest\n\ngo 1.16\n",
+		"main.go": main,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(gobin, "run", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s\n%s", err, out)
+	}
+	return string(out)
+}
+
+func TestWriteDialects(t *testing.T) {
+	fmt.Println("\n\nTestWriteDialects ***")
+
+	dir, err := os.MkdirTemp("", "gosql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fw := NewFileWriter(3)
+	fw.QueueBuilder(SelectBuilder().Select("q.ID", "q.Title").From("Questions", "q").
+		Where(C().EQ("q.TopicID", "?")).Limit(10), "ques", "list", "List questions")
+	fw.QueueBuilder(InsertBuilder().Table("Questions").Columns("Title"), "ques", "create", "Create question")
+	fw.Queue(StatementInfo{SQL: "select 1;", ReadOnly: true}, "db", "ping", "Check connection")
+	fw.QueueBuilder(CreateTableBuilder().Table("Topics").IfNotExists().Columns(Col("ID", "serial").PrimaryKey()), "db", "topics", "Create topics")
+
+	files, err := fw.renderDialects("sqlbuilder", "sqls", WriteGoCode, []string{DbTypePostgreSQL, DbTypeMsSQL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string][]string{
+		"sqlbuilder_pgsql.go": {
+			"//go:build pgsql\n",
+			"const QuesList string = \"select q.ID, q.Title from questions q where (q.TopicID=$1) limit 10;\"",
+			"const QuesCreate string = \"insert into Questions(Title) values($1);\"",
+			"const DbPing string = \"select 1;\"",
+			"const DbTopics string = \"create table if not exists Topics (ID serial primary key);\"",
+		},
+		"sqlbuilder_mssql.go": {
+			"//go:build mssql\n",
+			"const QuesList string = \"select top 10 q.ID, q.Title from questions q where (q.TopicID=@p1);\"",
+			"const QuesCreate string = \"insert into Questions(Title) values(@p1);\"",
+			"const DbPing string = \"select 1;\"",
+			"const DbTopics string = \"if object_id(N'Topics', N'U') is null create table Topics (ID int identity(1,1) primary key);\"",
+		},
+	}
+	if len(files) != len(exp) {
+		t.Fatalf("Expected %d files\nGot\n %d", len(exp), len(files))
+	}
+	for _, f := range files {
+		for _, e := range exp[f.name] {
+			if !strings.Contains(string(f.content), e) {
+				t.Errorf("Expected in %s\n %s\nGot\n %s", f.name, e, f.content)
+			}
+		}
+	}
+
+	// parameter format set for DATABASE_TYPE does not apply to other database types, unless set for them
+	os.Setenv("DATABASE_TYPE", DbTypePostgreSQL)
+	os.Setenv("PARAM_CHAR", "$p")
+	defer os.Unsetenv("PARAM_CHAR")
+	fw = NewFileWriter(1)
+	fw.QueueBuilder(SelectBuilder().Select("q.ID").From("Questions", "q").Where(C().EQ("q.ID", "?")), "ques", "get", "")
+	for _, tc := range []struct{ mysqlChar, mysql string }{
+		{"", "where (q.ID=?)"},
+		{":", "where (q.ID=:)"},
+	} {
+		if tc.mysqlChar != "" {
+			os.Setenv("PARAM_CHAR_MYSQL", tc.mysqlChar)
+			defer os.Unsetenv("PARAM_CHAR_MYSQL")
+		}
+		files, err = fw.renderDialects("sqlbuilder", "sqls", WriteGoCode, []string{DbTypePostgreSQL, DbTypeMsSQL, DbTypeMySQL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := map[string]string{
+			"sqlbuilder_pgsql.go": "where (q.ID=$p1)",
+			"sqlbuilder_mssql.go": "where (q.ID=@p1)",
+			"sqlbuilder_mysql.go": tc.mysql,
+		}
+		for _, f := range files {
+			if !strings.Contains(string(f.content), exp[f.name]) {
+				t.Errorf("Expected in %s\n %s\nGot\n %s", f.name, exp[f.name], f.content)
+			}
+		}
+	}
+	os.Unsetenv("PARAM_CHAR")
+	os.Unsetenv("PARAM_CHAR_MYSQL")
+
+	// misuse for one of database types and unknown database types are reported
+	fw = NewFileWriter(1)
+	fw.QueueBuilder(SelectBuilder().Select("q.ID").From("Questions", "q").DistinctOn("q.ID"), "ques", "first", "")
+	_, err = fw.renderDialects("sqlbuilder", "sqls", WriteGoCode, []string{DbTypePostgreSQL, DbTypeMySQL, "oracle"})
+	if err == nil || err.Error() != "unsupported database type 'oracle'" {
+		t.Errorf("Expected unsupported database type\nGot\n %v", err)
+	}
+	_, err = fw.renderDialects("sqlbuilder", "sqls", WriteGoCode, []string{DbTypePostgreSQL, DbTypeMySQL})
+	e := "mysql: cannot build statement 'QuesFirst': distinct on is not applicable to mssql/mysql"
+	if err == nil || err.Error() != e {
+		t.Errorf("Expected\n %s\nGot\n %v", e, err)
+	}
+
+	// loader selects database type at runtime
+	fw = NewFileWriter(1)
+	fw.QueueBuilder(SelectBuilder().Select("q.ID").From("Questions", "q").Where(C().EQ("q.ID", "?")).Limit(1),
+		"ques", "get", "Get question")
+	if err := os.Mkdir(path.Join(dir, "sqls"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	dialects := []string{DbTypePostgreSQL, DbTypeMsSQL}
+	if err := fw.WriteDialects(path.Join(dir, "sqls"), "sqlbuilder", "sqls", WriteJSONandJSONLoaderGoCode, dialects...); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := fw.CheckDialects(path.Join(dir, "sqls"), "sqlbuilder", "sqls", WriteJSONandJSONLoaderGoCode, dialects...)
+	if err != nil || diff != "" {
+		t.Errorf("Expected no diff\nGot\n %s %v", diff, err)
+	}
+
+	main := `package main
+
+import (
+	"fmt"
+
+	"loadertest/sqls"
+)
+
+func main() {
+	fmt.Println(sqls.LoadSQLs("sqls/sqlbuilder_pgsql.json"), sqls.LoadSQLs("sqls/sqlbuilder_mssql.json"))
+	fmt.Println(sqls.QuesGet().SQL)
+	fmt.Println(sqls.Use("mssql"), sqls.QuesGet().SQL)
+	fmt.Println(sqls.Use("mysql"), sqls.QuesGet().SQL)
+	fmt.Println(sqls.Reload())
+}
+`
+	out := runGoMain(t, dir, main)
+	e = "<nil> <nil>\n" +
+		"select q.ID from questions q where (q.ID=$1) limit 1;\n" +
+		"<nil> select top 1 q.ID from questions q where (q.ID=@p1);\n" +
+		"unsupported database type 'mysql' select top 1 q.ID from questions q where (q.ID=@p1);\n" +
+		"<nil>\n"
+	if out != e {
+		t.Errorf("Expected\n %s\nGot\n %s", e, out)
+	}
+}
+
+func TestSpec(t *testing.T) {
+	fmt.Println("\n\nTestSpec ***")
+
+	os.Setenv("DATABASE_TYPE", DbTypeMySQL)
+
+	dir, err := os.MkdirTemp("", "gosql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	js := `{
+  "package": "sqls", "output": ".", "dialect": "pgsql",
+  "queries": [
+    {"group": "ques", "key": "list", "description": "List questions",
+     "select": {"columns": ["q.ID", "q.Title"], "from": [{"table": "Questions", "alias": "q"}],
+                "where": [{"field": "q.TopicID", "op": "=", "value": "?"},
+                          {"field": "q.ID", "op": "in", "sub": {"columns": ["QID"], "from": [{"table": "TestQuestions"}],
+                                                                "where": [{"field": "TestID", "op": "=", "value": "?"}]}}],
+                "whereGroups": [{"op": "or", "conditions": [{"field": "q.Public", "op": "=", "value": "true"}]}],
+                "orderBy": [{"expr": "q.Title", "nulls": "last"}, {"position": 1, "desc": true}],
+                "limit": 10}},
+    {"group": "ques", "key": "create", "insert": {"table": "Questions", "columns": ["Title"], "returning": ["ID"]}},
+    {"group": "ques", "key": "vote",
+     "update": {"table": "Questions", "calc": [{"column": "Votes", "expr": "Votes+?"}],
+                "where": [{"field": "ID", "op": "=", "value": "?"}]}},
+    {"group": "ques", "key": "delete", "delete": {"table": "Questions", "where": [{"field": "ID", "op": "=", "value": "?"}]}},
+    {"group": "ques", "key": "archive", "proc": {"name": "archive_questions", "mode": "perform", "args": [{"name": "days"}]}}
+  ]
+}`
+	spec, err := ReadSpec(strings.NewReader(js))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.Write(dir); err != nil {
+		t.Fatal(err)
+	}
+	code, err := os.ReadFile(path.Join(dir, "sqlbuilder.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{
+		`const QuesList string = "select q.ID, q.Title from questions q where (q.ID IN (select QID from testquestions where (TestID=$1)) and q.TopicID=$2) OR (q.Public=true) order by q.Title asc nulls last, 1 desc limit 10;"`,
+		`const QuesCreate string = "insert into Questions(Title) values($1) returning ID;"`,
+		`const QuesVote string = "update Questions set Votes=Votes+$1 where (ID=$2);"`,
+		`const QuesDelete string = "delete from Questions where (ID=$1);"`,
+		`const QuesArchive string = "select archive_questions($1);"`,
+	}
+	for _, e := range exp {
+		if !strings.Contains(string(code), e) {
+			t.Errorf("Expected\n %s\nGot\n %s", e, code)
+		}
+	}
+	diff, err := spec.Check(dir)
+	if err != nil || diff != "" {
+		t.Errorf("Expected no diff\nGot\n %s %v", diff, err)
+	}
+
+	// unknown keys are rejected
+	if _, err := ReadSpec(strings.NewReader(`{"queries": [{"group": "a", "key": "b", "selct": {}}]}`)); err == nil {
+		t.Errorf("Expected error for unknown key")
+	}
+
+	// invalid queries are reported together
+	js = `{"package": "sqls", "queries": [
+    {"group": "a", "key": "b", "select": {"columns": ["id"], "from": [{"table": "t"}], "where": [{"field": "id", "op": "~", "value": "?"}]}},
+    {"group": "a", "key": "c", "insert": {"table": "t", "columns": ["id"]}, "delete": {"table": "t"}},
+    {"group": "a", "key": "d", "delete": {"table": "t"}}
+  ]}`
+	spec, err = ReadSpec(strings.NewReader(js))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = spec.Queue(NewFileWriter(3))
+	e := "query 'a.b': unknown operator '~' of field 'id'\n" +
+		"query 'a.c': exactly one of select, insert, update, delete and proc is required"
+	if err == nil || err.Error() != e {
+		t.Errorf("Expected\n %s\nGot\n %v", e, err)
+	}
+}
+
+func TestSpecYAML(t *testing.T) {
+	fmt.Println("\n\nTestSpecYAML ***")
+
+	js := `{
+  "package": "sqls", "output": ".", "dialect": "pgsql",
+  "queries": [
+    {"group": "ques", "key": "list", "description": "List questions",
+     "select": {"columns": ["q.ID", "q.Title"], "from": [{"table": "Questions", "alias": "q"}],
+                "where": [{"field": "q.TopicID", "op": "=", "value": "?"},
+                          {"field": "q.ID", "op": "in", "sub": {"columns": ["QID"], "from": [{"table": "TestQuestions"}],
+                                                                "where": [{"field": "TestID", "op": "=", "value": "?"}]}}],
+                "orderBy": [{"expr": "q.Title", "nulls": "last"}, {"position": 1, "desc": true}],
+                "limit": 10}},
+    {"group": "ques", "key": "vote",
+     "update": {"table": "Questions", "calc": [{"column": "Votes", "expr": "Votes+?"}],
+                "where": [{"field": "ID", "op": "=", "value": "?"}]}}
+  ]
+}`
+	exp, err := ReadSpec(strings.NewReader(js))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadSpec("testdata/queries.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected\n %+v\nGot\n %+v", exp, got)
+	}
+
+	// unknown keys are rejected as in JSON
+	if _, err := ReadSpecYAML(strings.NewReader("queries:\n  - {group: a, key: b, selct: {}}\n")); err == nil {
+		t.Errorf("Expected error for unknown key")
+	}
+}
+
+func TestWriteSchema(t *testing.T) {
+	fmt.Println("\n\nTestWriteSchema ***")
+
+	db := NewSchema()
+	db.AddTable("Questions",
+		Column{Name: "ID", Type: "serial", PrimaryKey: true},
+		Column{Name: "TopicID", Type: "int", NotNull: true},
+		Column{Name: "Title", Type: "text", NotNull: true})
+
+	fw := NewFileWriter(3)
+	fw.Schema(db)
+	fw.QueueBuilder(SelectBuilder().Select("q.ID", "q.Title").From("Questions", "q").
+		Where(C().EQ("q.TopicID", "?")), "ques", "list", "List questions")
+	fw.QueueBuilder(SelectBuilder().Select("q.ID", "q.Titel").From("Questions", "q").
+		Where(C().EQ("q.Topic", "?")), "ques", "byTopic", "List questions of topic")
+	fw.QueueBuilder(InsertBuilder().Table("Questions").Columns("Title"), "ques", "create", "Create question")
+
+	_, err := fw.render("sqlbuilder", "sqls", WriteGoCode)
+	exp := "statement 'QuesByTopic' does not match schema: unknown column 'Titel' of table 'Questions'; unknown column 'Topic' of table 'Questions'\n" +
+		"statement 'QuesCreate' does not match schema: missing required column 'TopicID' of table 'Questions'"
+	if err == nil || err.Error() != exp {
+		t.Errorf("Expected\n %s\nGot\n %v", exp, err)
+	}
+}
+
+func TestSpecSchema(t *testing.T) {
+	fmt.Println("\n\nTestSpecSchema ***")
+
+	dir, err := os.MkdirTemp("", "gosql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	migrations := map[string]string{
+		"001_users.up.sql":   "create table users (id serial primary key, name varchar(50) not null);",
+		"001_users.down.sql": "drop table users;",
+		"002_age.up.sql":     "alter table users add column age int;",
+	}
+	if err := os.Mkdir(path.Join(dir, "migrations"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, ddl := range migrations {
+		if err := os.WriteFile(path.Join(dir, "migrations", name), []byte(ddl), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	js := `{"package": "sqls", "dialect": "pgsql", "schema": ["migrations"], "queries": [
+    {"group": "user", "key": "list", "select": {"columns": ["u.id", "u.name", "u.age"], "from": [{"table": "users", "alias": "u"}]}},
+    {"group": "user", "key": "create", "insert": {"table": "users", "columns": ["age"]}}
+  ]}`
+	spec, err := ReadSpec(strings.NewReader(js))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = spec.Write(dir)
+	e := "statement 'UserCreate' does not match schema: missing required column 'name' of table 'users'"
+	if err == nil || err.Error() != e {
+		t.Errorf("Expected\n %s\nGot\n %v", e, err)
+	}
+
+	// names of tables are written to their own package
+	if err := os.Mkdir(path.Join(dir, "tbl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	spec.Queries = spec.Queries[:1]
+	spec.Tables = "tbl"
+	if err := spec.Write(dir); err != nil {
+		t.Fatal(err)
+	}
+	code, err := os.ReadFile(path.Join(dir, "tbl", "tables.go"))
+	if err != nil || !bytes.Contains(code, []byte("package tbl\n")) || !bytes.Contains(code, []byte("\tAge string\n")) {
+		t.Errorf("Expected tables code with column Age\nGot\n %s %v", code, err)
+	}
+	if diff, err := spec.Check(dir); err != nil || diff != "" {
+		t.Errorf("Expected no diff\nGot\n %s %v", diff, err)
+	}
+}
+
+func TestWriteTables(t *testing.T) {
+	fmt.Println("\n\nTestWriteTables ***")
+
+	db, err := ParseDDL(strings.NewReader(`
+create table questions (id serial primary key, topic_id int not null, title text, "table" text);
+create view question_titles as select id, title from questions;`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := db.renderTables("tables", "tbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(files[0].content)
+	exp := []string{
+		"type QuestionsTable struct {",
+		"\t// TopicID is column 'topic_id' int not null, qualified by alias if set.\n\tTopicID string\n",
+		"\tTableCol string\n",
+		`var Questions = QuestionsTable{Table: "questions", ID: "id", TopicID: "topic_id", Title: "title", TableCol: "table"}`,
+		`return QuestionsTable{Table: t.Table, Alias: alias, ID: alias + ".id", TopicID: alias + ".topic_id", Title: alias + ".title", TableCol: alias + ".table"}`,
+		"// QuestionTitlesTable holds names of view 'question_titles' and its columns.",
+	}
+	for _, e := range exp {
+		if !strings.Contains(code, e) {
+			t.Errorf("Expected\n%s\nGot\n%s", e, code)
+		}
+	}
+
+	// identifiers are strings usable in builders
+	use := `package tbl
+
+var q = Questions.As("q")
+var _ string = q.TopicID + q.Alias + q.Table + QuestionTitles.Title`
+	fset := token.NewFileSet()
+	var asts []*ast.File
+	for name, src := range map[string]string{"tables.go": code, "use.go": use} {
+		f, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		asts = append(asts, f)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("tbl", fset, asts, nil); err != nil {
+		t.Errorf("generated code does not compile: %s\n%s", err, code)
+	}
 
+	db.AddTable("question-titles")
+	if _, err := db.renderTables("tables", "tbl"); err == nil {
+		t.Errorf("Expected error for tables of same GO name")
+	}
 }